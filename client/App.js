@@ -7,6 +7,9 @@ import Leaderboard from './components/Leaderboard';
 
 const address = 'ec2-34-220-30-193.us-west-2.compute.amazonaws.com';
 
+// Snapshots the server keeps for deltas, older baselines are never used
+const SNAPSHOT_HISTORY = 64;
+
 export default class App extends React.Component {
   constructor(props) {
     super(props);
@@ -20,11 +23,23 @@ export default class App extends React.Component {
       junk: null,
       holes: null,
       players: null,
+      powerUps: null,
+      teams: null,
+      match: null,
+      results: null,
       playerAbsolutePosition: null,
       timeStarted: null,
       arena: null,
     };
 
+    // snapshots received, by number, that deltas are applied against
+    this.snapshots = new Map();
+    // inputs are numbered so the server can tell us the last one it processed,
+    // and the numbers keep increasing across reconnects since it remembers our player
+    this.inputSequence = 0;
+    // inputs sent that the server has not acknowledged yet, oldest first
+    this.pendingInputs = [];
+
     this.spawnPlayer = this.spawnPlayer.bind(this);
     this.connectPlayer = this.connectPlayer.bind(this);
     this.sendReconnectMessage = this.sendReconnectMessage.bind(this);
@@ -32,6 +47,10 @@ export default class App extends React.Component {
     this.initializeArena = this.initializeArena.bind(this);
     this.initializeGame = this.initializeGame.bind(this);
     this.sendKeyPress = this.sendKeyPress.bind(this);
+    this.sendAck = this.sendAck.bind(this);
+    this.receiveSnapshot = this.receiveSnapshot.bind(this);
+    this.applyDelta = this.applyDelta.bind(this);
+    this.acknowledgeInput = this.acknowledgeInput.bind(this);
     this.update = this.update.bind(this);
     this.tick = this.tick.bind(this);
     this.draw = this.draw.bind(this);
@@ -64,17 +83,36 @@ export default class App extends React.Component {
     const response = await fetch(`http://${address}/start`);
     const res = await response.json();
 
-    // Address of lobby to connect to
-    console.log(res.location);
+    // Address of the room to connect to, which reconnects also go back to
+    this.location = res.location;
 
     if (window.WebSocket) {
-      this.socket = new WebSocket(`ws://${address}/connect`);
-      this.socket.onopen = () => {
-        this.socket.onmessage = event => this.handleMessage(JSON.parse(event.data));
-      };
+      this.openSocket();
     }
   }
 
+  // open a connection to our room, resuming the player of the given session token if there is one
+  openSocket(token) {
+    const query = token ? `&token=${encodeURIComponent(token)}` : '';
+    this.socket = new WebSocket(`ws://${this.location}${query}`);
+    // the server starts every connection from a keyframe
+    this.snapshots.clear();
+    this.socket.onopen = () => {
+      this.socket.onmessage = event => this.handleMessage(JSON.parse(event.data));
+    };
+    this.socket.onclose = (event) => {
+      // the server keeps our player for a while after an unexpected disconnect,
+      // but gives a reason whenever it disconnects us on purpose
+      if (event.reason) {
+        console.warn(`Disconnected: ${event.reason}`);
+        this.sessionToken = null;
+      }
+      if (!event.wasClean && this.sessionToken) {
+        setTimeout(() => this.openSocket(this.sessionToken), 1000);
+      }
+    };
+  }
+
   // spawn player on submit
   spawnPlayer(name, country) {
     this.sendSpawnMessage(name, country);
@@ -115,15 +153,36 @@ export default class App extends React.Component {
   }
 
   sendKeyPress(key, isPressed) {
+    if (this.socket.readyState !== 1) {
+      return;
+    }
+
+    this.inputSequence += 1;
     const pressMessage = {
       key,
       isPressed,
+      sequence: this.inputSequence,
     };
     const message = {
       type: 'keyHandler',
       data: JSON.stringify(pressMessage),
     };
 
+    this.socket.send(JSON.stringify(message));
+    this.pendingInputs.push(pressMessage);
+  }
+
+  // drop the inputs the server has processed, up to and including sequence
+  acknowledgeInput(sequence) {
+    this.pendingInputs = this.pendingInputs.filter(input => input.sequence > sequence);
+  }
+
+  sendAck(snapshot) {
+    const message = {
+      type: 'ack',
+      data: JSON.stringify({ snapshot }),
+    };
+
     if (this.socket.readyState === 1) {
       this.socket.send(JSON.stringify(message));
     }
@@ -139,7 +198,30 @@ export default class App extends React.Component {
         this.openGameOverModal();
         break;
       case 'update':
-        this.update(msg.data);
+        this.receiveSnapshot(msg.data);
+        break;
+      case 'delta':
+        this.applyDelta(msg.data);
+        break;
+      case 'error':
+        console.warn(`Server error ${msg.data.code}: ${msg.data.message}`);
+        if (msg.data.code === 'invalid_name' || msg.data.code === 'invalid_country') {
+          // let the player pick another name
+          this.setState({ showWelcomeModal: true, showMiniMap: false });
+        }
+        break;
+      case 'phase':
+        // the last results stay up until the next match starts
+        this.setState({
+          match: msg.data,
+          results: msg.data.phase === 'warmup' ? null : this.state.results,
+        });
+        break;
+      case 'results':
+        this.setState({ results: msg.data });
+        break;
+      case 'rules':
+        this.setState({ arena: { width: msg.data.arenaWidth, height: msg.data.arenaHeight } });
         break;
       default:
         break;
@@ -148,9 +230,11 @@ export default class App extends React.Component {
 
   initializeArena(data) {
     this.state.player.id = data.playerID;
+    this.sessionToken = data.token;
     this.setState({
       arena: { width: data.arenaWidth, height: data.arenaHeight },
       player: this.state.player,
+      teams: data.teams,
       timeStarted: new Date(),
     });
   }
@@ -160,10 +244,68 @@ export default class App extends React.Component {
       junk: data.junk,
       holes: data.holes,
       players: data.players,
+      powerUps: data.powerUps,
+      teams: data.teams,
       isInitialized: true,
     }, () => this.tick());
   }
 
+  // keep a snapshot for later deltas, acknowledge it so the server sends deltas
+  // against it, and show it
+  receiveSnapshot(data) {
+    const snapshot = {
+      junk: data.junk || [],
+      holes: data.holes || [],
+      players: data.players || [],
+      powerUps: data.powerUps || [],
+      teams: data.teams,
+    };
+    this.snapshots.set(data.snapshot, snapshot);
+    this.snapshots.forEach((_, number) => {
+      if (number <= data.snapshot - SNAPSHOT_HISTORY) {
+        this.snapshots.delete(number);
+      }
+    });
+
+    if (data.input) {
+      this.acknowledgeInput(data.input.sequence);
+    }
+    this.sendAck(data.snapshot);
+    this.update(snapshot);
+  }
+
+  // rebuild a snapshot from the baseline a delta was built against
+  applyDelta(data) {
+    const baseline = this.snapshots.get(data.baseline);
+    if (!baseline) {
+      // the server falls back to a keyframe once our baseline is gone
+      return;
+    }
+
+    const removed = new Set(data.removed || []);
+    const merge = (objects, changed) => {
+      const byID = new Map();
+      objects.forEach((o) => {
+        if (!removed.has(o.id)) {
+          byID.set(o.id, o);
+        }
+      });
+      (changed || []).forEach(o => byID.set(o.id, o));
+      return Array.from(byID.values());
+    };
+
+    this.receiveSnapshot({
+      snapshot: data.snapshot,
+      junk: merge(baseline.junk, data.junk),
+      holes: merge(baseline.holes, data.holes),
+      players: merge(baseline.players, data.players),
+      powerUps: merge(baseline.powerUps, data.powerUps),
+      // teams are only sent when their scores changed
+      teams: data.teams || baseline.teams,
+      input: data.input,
+    });
+  }
+
   update(data) {
     if (!this.state.isInitialized) {
       this.initializeGame(data);
@@ -176,6 +318,8 @@ export default class App extends React.Component {
       junk: data.junk,
       holes: data.holes,
       players: data.players,
+      powerUps: data.powerUps,
+      teams: data.teams,
     });
   }
 
@@ -209,7 +353,12 @@ export default class App extends React.Component {
   render() {
     return (
       <div style={styles.canvasContainer}>
-        <Leaderboard players={this.state.players} />
+        <Leaderboard
+          players={this.state.players}
+          teams={this.state.teams}
+          match={this.state.match}
+          results={this.state.results}
+        />
         <canvas id="ctx" style={styles.canvas} display="inline" width={window.innerWidth - 20} height={window.innerHeight - 20} margin={0} />
         {
           this.state.showWelcomeModal &&
@@ -1,5 +1,13 @@
 const PLAYER_RADIUS = 25;
 const JUNK_SIZE = 15;
+const POWER_UP_RADIUS = 15;
+
+const effectColors = {
+  speed: '#F39C12',
+  shield: '#1ABC9C',
+  heavy: '#95A5A6',
+  magnet: '#9B59B6',
+};
 
 export function drawGame(data, canvas) {
   const rawPlayer = data.players.find(p => p.id === data.player.id);
@@ -103,6 +111,7 @@ export function drawGame(data, canvas) {
           color: p.color,
           angle: p.angle,
           name: p.name,
+          effects: p.effects,
         };
       }
       return {
@@ -110,12 +119,22 @@ export function drawGame(data, canvas) {
         color: p.color,
         angle: p.angle,
         name: p.name,
+        effects: p.effects,
       };
     });
 
+    const powerUps = (data.powerUps || []).map(pu => ({
+      position: {
+        x: pu.position.x + objectXTranslation,
+        y: pu.position.y + objectYTranslation,
+      },
+      effect: pu.effect,
+    }));
+
     // Draw all the new & translated game objects
     junk.forEach(j => drawJunk(j, canvas, 1));
     holes.forEach(h => drawHole(h, canvas));
+    powerUps.forEach(pu => drawPowerUp(pu, canvas));
     players.forEach(p => drawPlayer(p, canvas, 1));
   }
 }
@@ -155,6 +174,15 @@ export function drawHole(h, canvas) {
   ctx.closePath();
 }
 
+export function drawPowerUp(pu, canvas) {
+  const ctx = canvas.getContext('2d');
+  ctx.beginPath();
+  ctx.arc(pu.position.x, pu.position.y, POWER_UP_RADIUS, 0, 2 * Math.PI);
+  ctx.fillStyle = effectColors[pu.effect] || 'white';
+  ctx.fill();
+  ctx.closePath();
+}
+
 export function drawMapHole(h, canvas, scale) {
   const ctx = canvas.getContext('2d');
   ctx.beginPath();
@@ -268,6 +296,16 @@ export function drawPlayer(p, canvas, scale) {
     ctx.fill();
     ctx.closePath();
 
+    // Draw a ring around the rocket for each active effect
+    (p.effects || []).forEach((e, i) => {
+      ctx.beginPath();
+      ctx.arc(x, y, playerSize * (1.4 + (0.2 * i)), 0, 2 * Math.PI);
+      ctx.strokeStyle = effectColors[e.effect] || 'white';
+      ctx.lineWidth = 2;
+      ctx.stroke();
+      ctx.closePath();
+    });
+
     // TODO: Rocket Bottom piece
     // TODO: Rocket Window
   }
@@ -10,6 +10,17 @@ export default class Leaderboard extends React.Component {
     return (
       <div className="bg-light" style={styles.container}>
         <h2 className="bg-primary text-white p-2">Leaderboard</h2>
+        {
+          this.props.match &&
+          <p className="px-2">
+            {phaseNames[this.props.match.phase]}
+            {this.props.match.remaining > 0 && ` ${Math.ceil(this.props.match.remaining)}s`}
+          </p>
+        }
+        {
+          this.props.results &&
+          <p className="px-2">Winner: {this.props.results.winner}</p>
+        }
         <table className="table">
           <thead>
             <tr>
@@ -31,11 +42,40 @@ export default class Leaderboard extends React.Component {
             }
           </tbody>
         </table>
+        {
+          this.props.teams &&
+          <table className="table">
+            <thead>
+              <tr>
+                <th>Team</th>
+                <th>Score</th>
+              </tr>
+            </thead>
+            <tbody>
+              {
+                this.props.teams.map(t => (
+                  <tr key={t.id}>
+                    <td style={{ color: t.color }}>{t.name}</td>
+                    <td>{t.points}</td>
+                  </tr>
+                ))
+              }
+            </tbody>
+          </table>
+        }
       </div>
     );
   }
 }
 
+const phaseNames = {
+  warmup: 'Warming up',
+  countdown: 'Starting in',
+  inProgress: 'Match ends in',
+  results: 'Next match in',
+  reset: 'Resetting',
+};
+
 const styles = {
   container: {
     position: 'absolute',
@@ -3,41 +3,52 @@ package arena
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"sync"
 
-	"github.com/gorilla/websocket"
 	"github.com/ubclaunchpad/bumper/server/models"
 )
 
-// Arena related constants
-const (
-	MinDistanceBetween = models.MaxHoleRadius
-)
-
-// MessageChannel is used by the server to emit messages to a client (injected global from Main)
-var MessageChannel chan models.Message
-
 // Arena container for play area information including all objects
+// Mode decides how players score, die and spawn, and must be set before the arena is used
+// A power-up is added every PowerUpInterval seconds until there are MaxPowerUps
 type Arena struct {
-	rwMutex sync.RWMutex
-	Height  float64
-	Width   float64
-	Holes   []*models.Hole
-	Junk    []*models.Junk
-	Players map[string]*models.Player
+	rwMutex     sync.RWMutex
+	Height      float64
+	Width       float64
+	Holes       []*models.Hole
+	Junk        []*models.Junk
+	PowerUps    []*models.PowerUp
+	Players     map[string]*models.Player
+	Mode        GameMode
+	emitter     func(models.Message)
+	pending     []models.Message
+	players     *grid
+	junk        *grid
+	nextPowerUp float64
 }
 
 // CreateArena constructor for arena initializes holes and junk
-func CreateArena(height float64, width float64, holeCount int, junkCount int) *Arena {
+// Events for clients (such as deaths) are passed to the given emitter, which
+// is never called while the arena is locked
+// The arena plays free-for-all until its Mode is changed
+func CreateArena(height float64, width float64, holeCount int, junkCount int, emitter func(models.Message)) *Arena {
 	a := Arena{
 		sync.RWMutex{},
 		height,
 		width,
 		make([]*models.Hole, 0, holeCount),
 		make([]*models.Junk, 0, junkCount),
+		make([]*models.PowerUp, 0, models.MaxPowerUps),
 		make(map[string]*models.Player),
+		FreeForAll{},
+		emitter,
+		nil,
+		createGrid(height, width, GridCellSize),
+		createGrid(height, width, GridCellSize),
+		models.PowerUpInterval,
 	}
 
 	for i := 0; i < holeCount; i++ {
@@ -67,6 +78,14 @@ func (a *Arena) GetJunk() []*models.Junk {
 	return a.Junk
 }
 
+// GetPowerUps returns a list of power-ups
+func (a *Arena) GetPowerUps() []*models.PowerUp {
+	a.rwMutex.RLock()
+	defer a.rwMutex.RUnlock()
+
+	return a.PowerUps
+}
+
 // GetPlayers returns a list of players
 func (a *Arena) GetPlayers() []*models.Player {
 	a.rwMutex.RLock()
@@ -79,6 +98,48 @@ func (a *Arena) GetPlayers() []*models.Player {
 	return players
 }
 
+// GetSize returns the arena's height and width
+func (a *Arena) GetSize() (float64, float64) {
+	a.rwMutex.RLock()
+	defer a.rwMutex.RUnlock()
+	return a.Height, a.Width
+}
+
+// Resize changes the size of the arena, moving holes and junk that no longer
+// fit to random spots inside the new walls
+// Players outside the new walls are pushed back in on their next update
+func (a *Arena) Resize(height float64, width float64) {
+	a.rwMutex.Lock()
+	defer a.rwMutex.Unlock()
+
+	if height == a.Height && width == a.Width {
+		return
+	}
+
+	a.Height = height
+	a.Width = width
+	a.players = createGrid(height, width, GridCellSize)
+	a.junk = createGrid(height, width, GridCellSize)
+	a.rebuildGrids()
+
+	for _, hole := range a.Holes {
+		if !a.isInside(hole) {
+			hole.Position = a.generateCoordinate(models.MinHoleRadius)
+		}
+	}
+	for _, junk := range a.Junk {
+		if !a.isInside(junk) {
+			junk.Position = a.generateCoordinate(models.JunkRadius)
+		}
+	}
+	for _, powerUp := range a.PowerUps {
+		if !a.isInside(powerUp) {
+			powerUp.Position = a.generateCoordinate(models.PowerUpRadius)
+		}
+	}
+	a.rebuildGrids()
+}
+
 // UpdatePositions calculates the next state of each object
 func (a *Arena) UpdatePositions() {
 	a.rwMutex.Lock()
@@ -100,31 +161,62 @@ func (a *Arena) UpdatePositions() {
 			player.UpdatePosition(a.Height, a.Width)
 		}
 	}
+
+	a.nextPowerUp -= 1 / models.TickRate
+	if a.nextPowerUp <= 0 {
+		if len(a.PowerUps) < models.MaxPowerUps {
+			a.addPowerUp()
+		}
+		a.nextPowerUp = models.PowerUpInterval
+	}
 }
 
 // CollisionDetection loops through players and holes and determines if a collision has occurred
+// Events raised by collisions are emitted once the arena is unlocked, since
+// their receiver may need the arena to handle them
 func (a *Arena) CollisionDetection() {
 	a.rwMutex.Lock()
-	defer a.rwMutex.Unlock()
-
+	a.rebuildGrids()
 	a.playerCollisions()
 	a.holeCollisions()
 	a.junkCollisions()
+	a.powerUpCollisions()
+	events := a.pending
+	a.pending = nil
+	a.rwMutex.Unlock()
+
+	if a.emitter == nil {
+		return
+	}
+	for _, msg := range events {
+		a.emitter(msg)
+	}
 }
 
 // GetState assembles an UpdateMessage from the current state of the arena
 func (a *Arena) GetState() *models.UpdateMessage {
 	return &models.UpdateMessage{
-		Holes:   a.GetHoles(),
-		Junk:    a.GetJunk(),
-		Players: a.GetPlayers(),
+		Holes:    a.GetHoles(),
+		Junk:     a.GetJunk(),
+		Players:  a.GetPlayers(),
+		PowerUps: a.GetPowerUps(),
+		Teams:    a.GetTeams(),
 	}
 }
 
+// GetTeams returns the teams of the arena's mode and their scores, or nil if
+// players play for themselves
+func (a *Arena) GetTeams() []models.Team {
+	a.rwMutex.RLock()
+	defer a.rwMutex.RUnlock()
+
+	return a.Mode.Teams()
+}
+
 // AddPlayer adds a new player to the arena
 // player has no position or name until spawned
 // TODO player has no color until spawned
-func (a *Arena) AddPlayer(ws *websocket.Conn) (*models.Player, error) {
+func (a *Arena) AddPlayer(conn models.Connection) (*models.Player, error) {
 	a.rwMutex.Lock()
 	defer a.rwMutex.Unlock()
 
@@ -133,7 +225,7 @@ func (a *Arena) AddPlayer(ws *websocket.Conn) (*models.Player, error) {
 		return nil, err
 	}
 
-	p := models.CreatePlayer("", color, ws)
+	p := models.CreatePlayer("", color, conn)
 	a.Players[p.GetID()] = p
 	return p, nil
 }
@@ -154,18 +246,94 @@ func (a *Arena) RemovePlayer(p *models.Player) {
 }
 
 // SpawnPlayer spawns the player with a position on the map
-// TODO choose color here as well
+// Players can only spawn once, and names are expected to be validated already
+// The arena's mode may prepare the player or refuse to spawn it
 func (a *Arena) SpawnPlayer(id string, name string, country string) error {
 	a.rwMutex.Lock()
 	defer a.rwMutex.Unlock()
 
-	position := a.generateCoordinate(models.PlayerRadius)
-	a.Players[id].Position = position
-	a.Players[id].Name = name
-	a.Players[id].Country = country
+	p, ok := a.Players[id]
+	if !ok {
+		return &models.ErrorMessage{Code: models.ErrorSpawnFailed, Message: fmt.Sprintf("player %s is not in the arena", id)}
+	}
+	if p.Name != "" {
+		return &models.ErrorMessage{Code: models.ErrorAlreadySpawned, Message: fmt.Sprintf("player %s has already spawned", id)}
+	}
+
+	p.Name = name
+	p.Country = country
+	err := a.Mode.Spawn(p, a.Players)
+	if err != nil {
+		p.Name = ""
+		p.Country = ""
+		return err
+	}
+
+	p.Position = a.generateCoordinate(models.PlayerRadius)
+	a.players.insert(p)
 	return nil
 }
 
+// Reset replaces the holes and junk with holeCount fresh holes and junkCount
+// fresh junk, moves every spawned player to a new spot at rest and clears
+// every score, ready for a new match
+func (a *Arena) Reset(holeCount int, junkCount int) {
+	a.rwMutex.Lock()
+	defer a.rwMutex.Unlock()
+
+	a.Holes = make([]*models.Hole, 0, holeCount)
+	a.Junk = make([]*models.Junk, 0, junkCount)
+	a.PowerUps = make([]*models.PowerUp, 0, models.MaxPowerUps)
+	a.nextPowerUp = models.PowerUpInterval
+	a.players.clear()
+	a.junk.clear()
+
+	for i := 0; i < holeCount; i++ {
+		a.addHole()
+	}
+	for i := 0; i < junkCount; i++ {
+		a.addJunk()
+	}
+	for _, player := range a.Players {
+		if player.Name != "" {
+			a.respawn(player)
+			player.ClearEffects()
+			a.players.insert(player)
+		}
+	}
+	a.resetScores()
+}
+
+// ResetScores clears the score of every player and of the arena's mode
+func (a *Arena) ResetScores() {
+	a.rwMutex.Lock()
+	defer a.rwMutex.Unlock()
+
+	a.resetScores()
+}
+
+func (a *Arena) resetScores() {
+	for _, player := range a.Players {
+		player.Points = 0
+	}
+	a.Mode.Reset()
+}
+
+// Winner returns who won once the arena's mode declares the match won, and
+// false while it goes on
+func (a *Arena) Winner() (string, bool) {
+	a.rwMutex.RLock()
+	defer a.rwMutex.RUnlock()
+
+	return a.Mode.Winner(a.Players)
+}
+
+// emit queues a message for the arena's owner, to be emitted once the arena is unlocked
+// It is called with the arena locked
+func (a *Arena) emit(msg models.Message) {
+	a.pending = append(a.pending, msg)
+}
+
 // generateCoordinate creates a position coordinate
 // coordinates are constrained within the Arena's width/height and spacing
 // they are all valid
@@ -175,7 +343,7 @@ func (a *Arena) generateCoordinate(objectRadius float64) models.Position {
 
 	dummy := models.Hole{
 		Position: models.Position{},
-		Radius:   MinDistanceBetween,
+		Radius:   models.MaxHoleRadius,
 	}
 	for {
 		x := math.Floor(rand.Float64()*(maxWidth)) + objectRadius
@@ -193,18 +361,40 @@ func (a *Arena) isPositionValid(obj models.Object) bool {
 			return false
 		}
 	}
-	for _, junk := range a.Junk {
-		if areCirclesColliding(junk, obj) {
-			return false
-		}
+
+	valid := true
+	checkCollision := func(other models.Object) {
+		valid = valid && !areCirclesColliding(other, obj)
 	}
+	a.junk.query(obj.GetPosition(), obj.GetRadius()+models.JunkRadius, checkCollision)
+	a.players.query(obj.GetPosition(), obj.GetRadius()+models.PlayerRadius, checkCollision)
+
+	return valid
+}
+
+// isInside returns whether the object's center lies within the arena
+func (a *Arena) isInside(obj models.Object) bool {
+	position := obj.GetPosition()
+	return position.X >= 0 && position.X <= a.Width && position.Y >= 0 && position.Y <= a.Height
+}
+
+// rebuildGrids repartitions every player and junk by its current position
+func (a *Arena) rebuildGrids() {
+	a.players.clear()
 	for _, player := range a.Players {
-		if areCirclesColliding(player, obj) {
-			return false
-		}
+		a.players.insert(player)
 	}
 
-	return true
+	a.junk.clear()
+	for _, junk := range a.Junk {
+		a.junk.insert(junk)
+	}
+}
+
+// isPlaying returns whether the player is still in the arena
+// Eliminated players stay in the grids until they are next rebuilt
+func (a *Arena) isPlaying(p *models.Player) bool {
+	return a.Players[p.GetID()] == p
 }
 
 // detect collision between objects
@@ -219,27 +409,52 @@ func areCirclesColliding(obj models.Object, other models.Object) bool {
 collisionPlayer checks for collisions between players to junk, holes, and other players
 Duplicate calculations are kept track of using the memo map to store collisions detected
 between player-to-player.
+Only players and junk in nearby grid cells are compared.
 */
 func (a *Arena) playerCollisions() {
 	memo := make(map[*models.Player]*models.Player)
 	for _, player := range a.Players {
-		for _, playerHit := range a.Players {
+		a.players.query(player.GetPosition(), 2*models.PlayerRadius, func(obj models.Object) {
+			playerHit := obj.(*models.Player)
 			if player == playerHit || memo[player] == playerHit {
-				continue
+				return
 			}
 			if areCirclesColliding(player, playerHit) {
 				memo[playerHit] = player
 				player.HitPlayer(playerHit)
 			}
-		}
-		for _, junk := range a.Junk {
+		})
+		a.junk.query(player.GetPosition(), models.PlayerRadius+models.JunkRadius, func(obj models.Object) {
+			junk := obj.(*models.Junk)
 			if areCirclesColliding(player, junk) {
 				junk.HitBy(player)
 			}
+		})
+		if player.HasEffect(models.MagnetEffect) {
+			a.junk.query(player.GetPosition(), models.MagnetRadius, func(obj models.Object) {
+				obj.(*models.Junk).AttractTo(player)
+			})
 		}
 	}
 }
 
+// powerUpCollisions grants each power-up's effect to the first spawned player touching it
+func (a *Arena) powerUpCollisions() {
+	for i := 0; i < len(a.PowerUps); i++ {
+		powerUp := a.PowerUps[i]
+		a.players.query(powerUp.GetPosition(), models.PowerUpRadius+models.PlayerRadius, func(obj models.Object) {
+			player := obj.(*models.Player)
+			if powerUp == nil || player.GetName() == "" || !a.isPlaying(player) || !areCirclesColliding(player, powerUp) {
+				return
+			}
+			player.AddEffect(powerUp.Effect)
+			a.removePowerUp(i)
+			powerUp = nil
+			i--
+		})
+	}
+}
+
 func (a *Arena) holeCollisions() {
 	for _, hole := range a.Holes {
 		if !hole.IsAlive {
@@ -251,29 +466,42 @@ func (a *Arena) holeCollisions() {
 			Radius:   hole.GetGravityRadius(),
 		}
 
-		for name, player := range a.Players {
+		a.players.query(hole.GetPosition(), gravityField.Radius+models.PlayerRadius, func(obj models.Object) {
+			player := obj.(*models.Player)
+			if !a.isPlaying(player) || player.HasEffect(models.ShieldEffect) {
+				return
+			}
 			if areCirclesColliding(player, hole) {
-				playerScored := player.LastPlayerHit
-				if playerScored != nil {
-					playerScored.AddPoints(models.PointsPerPlayer)
-					// go database.UpdatePlayerScore(playerScored)
+				switch a.Mode.PlayerDied(player, player.LastPlayerHit) {
+				case Eliminate:
+					// the player is removed right away so it cannot die again this tick,
+					// and the death message carries it since it can no longer be looked up
+					delete(a.Players, player.GetID())
+					deathMsg := models.Message{
+						Type: "death",
+						Data: player,
+					}
+					a.emit(deathMsg)
+				case Respawn:
+					a.respawn(player)
 				}
-
-				deathMsg := models.Message{
-					Type: "death",
-					Data: name,
-				}
-				MessageChannel <- deathMsg
 			} else if areCirclesColliding(player, gravityField) {
 				player.ApplyGravity(hole)
 			}
-		}
+		})
 
-		for i, junk := range a.Junk {
+		a.junk.query(hole.GetPosition(), gravityField.Radius+models.JunkRadius, func(obj models.Object) {
+			junk := obj.(*models.Junk)
 			if areCirclesColliding(junk, hole) {
+				// the junk may already have fallen into another hole this tick
+				i := a.junkIndex(junk)
+				if i < 0 {
+					return
+				}
+
 				playerScored := junk.LastPlayerHit
 				if playerScored != nil {
-					playerScored.AddPoints(models.PointsPerJunk)
+					a.Mode.JunkScored(playerScored)
 				}
 
 				a.removeJunk(i)
@@ -281,23 +509,31 @@ func (a *Arena) holeCollisions() {
 			} else if areCirclesColliding(junk, gravityField) {
 				junk.ApplyGravity(hole)
 			}
-		}
+		})
 	}
 }
 
+// respawn moves a player that fell into a hole to a new spot, at rest
+func (a *Arena) respawn(p *models.Player) {
+	p.Position = a.generateCoordinate(models.PlayerRadius)
+	p.Velocity = models.Velocity{}
+	p.LastPlayerHit = nil
+}
+
 // Checks for junk on junk collisions
 func (a *Arena) junkCollisions() {
 	memo := make(map[*models.Junk]*models.Junk)
 	for _, junk := range a.Junk {
-		for _, junkHit := range a.Junk {
+		a.junk.query(junk.GetPosition(), 2*models.JunkRadius, func(obj models.Object) {
+			junkHit := obj.(*models.Junk)
 			if junk == junkHit || memo[junkHit] == junk {
-				continue
+				return
 			}
 			if areCirclesColliding(junk, junkHit) {
 				memo[junkHit] = junk
 				junk.HitJunk(junkHit)
 			}
-		}
+		})
 	}
 }
 
@@ -338,6 +574,17 @@ func (a *Arena) addJunk() {
 	position := a.generateCoordinate(models.JunkRadius)
 	junk := models.CreateJunk(position)
 	a.Junk = append(a.Junk, junk)
+	a.junk.insert(junk)
+}
+
+// returns the index of the given junk, or -1 if it is not in the arena
+func (a *Arena) junkIndex(junk *models.Junk) int {
+	for i, j := range a.Junk {
+		if j == junk {
+			return i
+		}
+	}
+	return -1
 }
 
 // remove junk without considering order
@@ -358,6 +605,20 @@ func (a *Arena) addHole() {
 	a.Holes = append(a.Holes, h)
 }
 
+// addPowerUp places a power-up with a random effect at a free spot
+func (a *Arena) addPowerUp() {
+	position := a.generateCoordinate(models.PowerUpRadius)
+	a.PowerUps = append(a.PowerUps, models.CreatePowerUp(position, models.RandomEffect()))
+}
+
+// removePowerUp removes the power-up at index
+// The list is replaced rather than changed in place, as it may still be being sent to clients
+func (a *Arena) removePowerUp(index int) {
+	powerUps := make([]*models.PowerUp, 0, cap(a.PowerUps))
+	powerUps = append(powerUps, a.PowerUps[:index]...)
+	a.PowerUps = append(powerUps, a.PowerUps[index+1:]...)
+}
+
 // remove hole without considering order
 func (a *Arena) removeHole(index int) bool {
 	if len(a.Holes) < index+1 {
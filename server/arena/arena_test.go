@@ -21,7 +21,7 @@ var (
 )
 
 func CreateArenaWithPlayer(p models.Position) (*Arena, *models.Player) {
-	a := CreateArena(testHeight, testWidth, 0, 0)
+	a := CreateArena(testHeight, testWidth, 0, 0, nil)
 	player, _ := a.AddPlayer(nil)
 	testPlayer := a.Players[player.GetID()]
 	testPlayer.Position = p
@@ -30,7 +30,7 @@ func CreateArenaWithPlayer(p models.Position) (*Arena, *models.Player) {
 }
 
 func TestCreateArena(t *testing.T) {
-	a := CreateArena(testHeight, testWidth, testHoleCount, testJunkCount)
+	a := CreateArena(testHeight, testWidth, testHoleCount, testJunkCount, nil)
 
 	holeCount := len(a.Holes)
 	if holeCount != testHoleCount {
@@ -43,8 +43,31 @@ func TestCreateArena(t *testing.T) {
 	}
 }
 
+func TestResize(t *testing.T) {
+	a := CreateArena(testHeight, testWidth, testHoleCount, testJunkCount, nil)
+	a.Resize(testHeight/2, testWidth/2)
+
+	height, width := a.GetSize()
+	if height != testHeight/2 || width != testWidth/2 {
+		t.Fatalf("Expected arena to be %dx%d, got %gx%g", testWidth/2, testHeight/2, width, height)
+	}
+	if len(a.Holes) != testHoleCount || len(a.Junk) != testJunkCount {
+		t.Errorf("Expected resize to keep every object, got %d holes and %d junk", len(a.Holes), len(a.Junk))
+	}
+	for _, hole := range a.Holes {
+		if !a.isInside(hole) {
+			t.Errorf("Hole at %v is outside the resized arena", hole.Position)
+		}
+	}
+	for _, junk := range a.Junk {
+		if !a.isInside(junk) {
+			t.Errorf("Junk at %v is outside the resized arena", junk.Position)
+		}
+	}
+}
+
 func TestAddPlayer(t *testing.T) {
-	a := CreateArena(testHeight, testWidth, testHoleCount, testJunkCount)
+	a := CreateArena(testHeight, testWidth, testHoleCount, testJunkCount, nil)
 
 	numPlayers := 3
 	for i := 0; i < numPlayers; i++ {
@@ -63,8 +86,41 @@ func TestAddPlayer(t *testing.T) {
 	}
 }
 
+func TestSpawnPlayer(t *testing.T) {
+	a := CreateArena(testHeight, testWidth, 0, 0, nil)
+	p, err := a.AddPlayer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		description string
+		id          string
+		want        string
+	}{
+		{"Spawn", p.GetID(), ""},
+		{"Spawn twice", p.GetID(), models.ErrorAlreadySpawned},
+		{"Spawn missing player", "missing", models.ErrorSpawnFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := a.SpawnPlayer(tc.id, "testy", "CA")
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("Expected spawn to succeed, got %v", err)
+				}
+				return
+			}
+			if e, ok := err.(*models.ErrorMessage); !ok || e.Code != tc.want {
+				t.Errorf("Expected error %s, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
 func TestAddRemoveObject(t *testing.T) {
-	a := CreateArena(testHeight, testWidth, 0, 0)
+	a := CreateArena(testHeight, testWidth, 0, 0, nil)
 
 	testCount := 10
 	testCases := []struct {
@@ -112,6 +168,7 @@ func TestPlayerToPlayerCollisions(t *testing.T) {
 			otherPlayer, _ := a.AddPlayer(nil)
 			otherPlayer.Position = tc.testPosition
 
+			a.rebuildGrids()
 			a.playerCollisions()
 			a.UpdatePositions()
 
@@ -125,19 +182,26 @@ func TestPlayerToPlayerCollisions(t *testing.T) {
 func TestPlayerToJunkCollisions(t *testing.T) {
 	a, p := CreateArenaWithPlayer(quarterPosition)
 
+	// junk just ahead of the player is only taken if the player moves into it
+	ahead := models.Position{X: quarterPosition.X + 10, Y: quarterPosition.Y}
 	testCases := []struct {
 		description    string
 		testPosition   models.Position
+		playerVelocity models.Velocity
 		expectedPlayer *models.Player
 	}{
-		{"non-colliding", centerPosition, nil},
-		{"colliding", quarterPosition, p},
+		{"non-colliding", centerPosition, models.Velocity{Dx: 5}, nil},
+		{"colliding", ahead, models.Velocity{Dx: 5}, p},
+		{"moving apart", ahead, models.Velocity{Dx: -5}, nil},
 	}
 	for i, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
+			p.Position = quarterPosition
+			p.Velocity = tc.playerVelocity
 			a.addJunk()
 			a.Junk[i].Position = tc.testPosition
 
+			a.rebuildGrids()
 			a.playerCollisions()
 			if a.Junk[i].LastPlayerHit != tc.expectedPlayer {
 				t.Errorf("%s detection failed. Test Player at %v. Junk at %v. Junk Last Player Hit %v", tc.description, p.Position, a.Junk[i].Position, a.Junk[i].LastPlayerHit)
@@ -153,11 +217,11 @@ func TestJunkToJunkCollisions(t *testing.T) {
 		expectedVelocity models.Velocity
 	}{
 		{"non-colliding", centerPosition, testVelocity},
-		{"colliding", quarterPosition, models.Velocity{Dx: -0.5, Dy: -0.5}},
+		{"glancing", models.Position{X: quarterPosition.X + models.JunkRadius, Y: quarterPosition.Y}, models.Velocity{Dx: (1 - models.JunkRestitution) / 2, Dy: 1}},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
-			a := CreateArena(testHeight, testWidth, 0, 0)
+			a := CreateArena(testHeight, testWidth, 0, 0, nil)
 			a.addJunk()
 			a.Junk[0].Position = quarterPosition
 			a.Junk[0].Velocity = testVelocity
@@ -165,6 +229,7 @@ func TestJunkToJunkCollisions(t *testing.T) {
 			a.addJunk()
 			a.Junk[1].Position = tc.testPosition
 
+			a.rebuildGrids()
 			a.junkCollisions()
 			if a.Junk[0].Velocity != tc.expectedVelocity {
 				t.Errorf("%s detection failed. Expected %v. Got %v", tc.description, tc.expectedVelocity, a.Junk[0].Velocity)
@@ -173,9 +238,110 @@ func TestJunkToJunkCollisions(t *testing.T) {
 	}
 }
 
-// TODO: Complete once Game package refactoring has happened
 func TestHoleToPlayerCollisions(t *testing.T) {
+	testCases := []struct {
+		description   string
+		holePosition  models.Position
+		shielded      bool
+		expectedDeath bool
+	}{
+		{"non-colliding", centerPosition, false, false},
+		{"colliding", quarterPosition, false, true},
+		{"shielded", quarterPosition, true, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			// the emitter needs the arena, so it must be called once the arena is unlocked
+			var a *Arena
+			var messages []models.Message
+			a = CreateArena(testHeight, testWidth, 0, 0, func(msg models.Message) {
+				a.GetSize()
+				messages = append(messages, msg)
+			})
+			p, _ := a.AddPlayer(nil)
+			p.Position = quarterPosition
+			if tc.shielded {
+				p.AddEffect(models.ShieldEffect)
+			}
+
+			h := models.CreateHole(tc.holePosition)
+			h.IsAlive = true
+			a.Holes = append(a.Holes, h)
+
+			a.CollisionDetection()
+			switch {
+			case len(messages) > 0:
+				msg := messages[0]
+				if !tc.expectedDeath || msg.Type != "death" || msg.Data != p {
+					t.Errorf("%s detection failed. Got unexpected %s message for %v", tc.description, msg.Type, msg.Data)
+				}
+			default:
+				if tc.expectedDeath {
+					t.Errorf("%s detection failed. No death message emitted for player at %v", tc.description, p.Position)
+				}
+			}
+		})
+	}
+}
+
+func TestEliminatedOnce(t *testing.T) {
+	var messages []models.Message
+	a := CreateArena(testHeight, testWidth, 0, 0, func(msg models.Message) {
+		messages = append(messages, msg)
+	})
+	p, _ := a.AddPlayer(nil)
+	p.Position = quarterPosition
+	killer := models.CreatePlayer("killer", "", nil)
+	p.LastPlayerHit = killer
+
+	// the player falls into two overlapping holes at once
+	for i := 0; i < 2; i++ {
+		h := models.CreateHole(quarterPosition)
+		h.IsAlive = true
+		a.Holes = append(a.Holes, h)
+	}
 
+	a.CollisionDetection()
+	if len(messages) != 1 {
+		t.Errorf("Expected one death message, got %v", messages)
+	}
+	if killer.Points != models.PointsPerPlayer {
+		t.Errorf("Expected the killer to score once, got %d points", killer.Points)
+	}
+	if a.GetPlayer(p.GetID()) != nil {
+		t.Error("Expected the eliminated player to be removed from the arena")
+	}
+}
+
+func TestPowerUps(t *testing.T) {
+	a := CreateArena(testHeight, testWidth, 0, 0, nil)
+	unspawned, _ := a.AddPlayer(nil)
+	unspawned.Position = quarterPosition
+	p, _ := a.AddPlayer(nil)
+	a.SpawnPlayer(p.GetID(), "testy", "CA")
+	p.Position = quarterPosition
+
+	for i := 0; i < models.MaxPowerUps+1; i++ {
+		a.nextPowerUp = 0
+		a.UpdatePositions()
+	}
+	if len(a.PowerUps) != models.MaxPowerUps {
+		t.Fatalf("Expected %d power-ups to spawn, got %d", models.MaxPowerUps, len(a.PowerUps))
+	}
+
+	a.PowerUps[0].Position = quarterPosition
+	effect := a.PowerUps[0].Effect
+	a.CollisionDetection()
+
+	if len(a.PowerUps) != models.MaxPowerUps-1 {
+		t.Errorf("Expected the power-up to be picked up, %d left", len(a.PowerUps))
+	}
+	if !p.HasEffect(effect) {
+		t.Errorf("Expected the player to have the %s effect, got %v", effect, p.Effects)
+	}
+	if len(unspawned.Effects) != 0 {
+		t.Errorf("Expected unspawned players not to pick up power-ups, got %v", unspawned.Effects)
+	}
 }
 
 // TODO: Complete once Game package refactoring has happened
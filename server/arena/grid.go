@@ -0,0 +1,87 @@
+package arena
+
+import (
+	"math"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// Grid related constants
+const (
+	GridCellSize = 100
+)
+
+// grid is a uniform spatial partition of the arena used as a broad phase for
+// collision detection, so only objects in nearby cells have to be compared
+type grid struct {
+	cellSize float64
+	columns  int
+	rows     int
+	cells    [][]models.Object
+}
+
+// createGrid constructs an empty grid covering an area of the given size
+func createGrid(height float64, width float64, cellSize float64) *grid {
+	columns := int(math.Ceil(width/cellSize)) + 1
+	rows := int(math.Ceil(height/cellSize)) + 1
+	return &grid{
+		cellSize: cellSize,
+		columns:  columns,
+		rows:     rows,
+		cells:    make([][]models.Object, columns*rows),
+	}
+}
+
+// clear empties every cell while keeping its allocated capacity
+func (g *grid) clear() {
+	for i := range g.cells {
+		g.cells[i] = g.cells[i][:0]
+	}
+}
+
+// insert adds the object to the cell containing its position
+func (g *grid) insert(obj models.Object) {
+	position := obj.GetPosition()
+	i := g.cellIndex(g.column(position.X), g.row(position.Y))
+	g.cells[i] = append(g.cells[i], obj)
+}
+
+// query calls visit for every object in a cell overlapping the square
+// of the given radius around position
+// Callers still need to check each object for an actual collision
+func (g *grid) query(position models.Position, radius float64, visit func(models.Object)) {
+	minColumn, maxColumn := g.column(position.X-radius), g.column(position.X+radius)
+	minRow, maxRow := g.row(position.Y-radius), g.row(position.Y+radius)
+
+	for row := minRow; row <= maxRow; row++ {
+		for column := minColumn; column <= maxColumn; column++ {
+			for _, obj := range g.cells[g.cellIndex(column, row)] {
+				visit(obj)
+			}
+		}
+	}
+}
+
+func (g *grid) cellIndex(column int, row int) int {
+	return row*g.columns + column
+}
+
+// column returns the column containing x, clamped to the grid
+func (g *grid) column(x float64) int {
+	return clamp(int(math.Floor(x/g.cellSize)), 0, g.columns-1)
+}
+
+// row returns the row containing y, clamped to the grid
+func (g *grid) row(y float64) int {
+	return clamp(int(math.Floor(y/g.cellSize)), 0, g.rows-1)
+}
+
+func clamp(v int, min int, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,182 @@
+package arena
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+func TestGridQuery(t *testing.T) {
+	g := createGrid(testHeight, testWidth, GridCellSize)
+
+	near := models.CreateJunk(models.Position{X: 150, Y: 150})
+	far := models.CreateJunk(models.Position{X: 1500, Y: 1500})
+	outside := models.CreateJunk(models.Position{X: -20, Y: testHeight + 20})
+	g.insert(near)
+	g.insert(far)
+	g.insert(outside)
+
+	testCases := []struct {
+		description string
+		position    models.Position
+		radius      float64
+		expected    map[models.Object]bool
+	}{
+		{"near", models.Position{X: 120, Y: 120}, 50, map[models.Object]bool{near: true}},
+		{"far", models.Position{X: 1500, Y: 1500}, 10, map[models.Object]bool{far: true}},
+		{"outside arena", models.Position{X: 0, Y: testHeight}, 10, map[models.Object]bool{outside: true}},
+		{"empty", models.Position{X: 800, Y: 800}, 10, map[models.Object]bool{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			visited := make(map[models.Object]bool)
+			g.query(tc.position, tc.radius, func(obj models.Object) {
+				visited[obj] = true
+			})
+
+			for obj := range tc.expected {
+				if !visited[obj] {
+					t.Errorf("Query at %v did not visit object at %v", tc.position, obj.GetPosition())
+				}
+			}
+			if len(visited) != len(tc.expected) {
+				t.Errorf("Query at %v visited %d objects. Expected %d", tc.position, len(visited), len(tc.expected))
+			}
+		})
+	}
+}
+
+func TestGridMatchesBruteForce(t *testing.T) {
+	a := createCrowdedArena(100, 1000)
+	a.rebuildGrids()
+
+	for _, player := range a.Players {
+		expected := 0
+		for _, junk := range a.Junk {
+			if areCirclesColliding(player, junk) {
+				expected++
+			}
+		}
+
+		found := 0
+		a.junk.query(player.GetPosition(), models.PlayerRadius+models.JunkRadius, func(obj models.Object) {
+			if areCirclesColliding(player, obj) {
+				found++
+			}
+		})
+		if found != expected {
+			t.Errorf("Grid found %d junk colliding with player at %v. Expected %d", found, player.Position, expected)
+		}
+	}
+}
+
+// createCrowdedArena fills an arena with randomly placed players and junk,
+// ignoring the spacing normally enforced when spawning objects
+func createCrowdedArena(playerCount int, junkCount int) *Arena {
+	a := CreateArena(testHeight, testWidth, testHoleCount, 0, nil)
+	randomPosition := func() models.Position {
+		return models.Position{X: rand.Float64() * testWidth, Y: rand.Float64() * testHeight}
+	}
+
+	for i := 0; i < playerCount; i++ {
+		p, _ := a.AddPlayer(nil)
+		p.Position = randomPosition()
+	}
+	for i := 0; i < junkCount; i++ {
+		a.Junk = append(a.Junk, models.CreateJunk(randomPosition()))
+	}
+	return a
+}
+
+// bruteForceCollisionDetection compares every pair of objects, as collision
+// detection did before the arena was partitioned into a grid
+func bruteForceCollisionDetection(a *Arena) {
+	playerMemo := make(map[*models.Player]*models.Player)
+	for _, player := range a.Players {
+		for _, playerHit := range a.Players {
+			if player == playerHit || playerMemo[player] == playerHit {
+				continue
+			}
+			if areCirclesColliding(player, playerHit) {
+				playerMemo[playerHit] = player
+				player.HitPlayer(playerHit)
+			}
+		}
+		for _, junk := range a.Junk {
+			if areCirclesColliding(player, junk) {
+				junk.HitBy(player)
+			}
+		}
+	}
+
+	for _, hole := range a.Holes {
+		gravityField := models.Hole{
+			Position: hole.GetPosition(),
+			Radius:   hole.GetGravityRadius(),
+		}
+		for _, player := range a.Players {
+			if !areCirclesColliding(player, hole) && areCirclesColliding(player, gravityField) {
+				player.ApplyGravity(hole)
+			}
+		}
+		for _, junk := range a.Junk {
+			if !areCirclesColliding(junk, hole) && areCirclesColliding(junk, gravityField) {
+				junk.ApplyGravity(hole)
+			}
+		}
+	}
+
+	junkMemo := make(map[*models.Junk]*models.Junk)
+	for _, junk := range a.Junk {
+		for _, junkHit := range a.Junk {
+			if junk == junkHit || junkMemo[junkHit] == junk {
+				continue
+			}
+			if areCirclesColliding(junk, junkHit) {
+				junkMemo[junkHit] = junk
+				junk.HitJunk(junkHit)
+			}
+		}
+	}
+}
+
+func BenchmarkCollisionDetection(b *testing.B) {
+	sizes := []struct {
+		players int
+		junk    int
+	}{
+		{10, 30},
+		{100, 1000},
+		{300, 3000},
+	}
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("grid/%d players/%d junk", size.players, size.junk), func(b *testing.B) {
+			a := createCrowdedArena(size.players, size.junk)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				a.CollisionDetection()
+			}
+		})
+		b.Run(fmt.Sprintf("brute force/%d players/%d junk", size.players, size.junk), func(b *testing.B) {
+			a := createCrowdedArena(size.players, size.junk)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				bruteForceCollisionDetection(a)
+			}
+		})
+	}
+}
+
+func BenchmarkSpawnPosition(b *testing.B) {
+	a := createCrowdedArena(100, 300)
+	a.rebuildGrids()
+
+	dummy := models.Hole{Radius: models.MaxHoleRadius}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dummy.Position = models.Position{X: rand.Float64() * testWidth, Y: rand.Float64() * testHeight}
+		a.isPositionValid(&dummy)
+	}
+}
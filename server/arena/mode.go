@@ -0,0 +1,114 @@
+package arena
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// Death is what happens to a player that falls into a hole
+type Death int
+
+// Outcomes of falling into a hole
+const (
+	// Eliminate removes the player from the arena and tells its client it died
+	Eliminate Death = iota
+	// Respawn moves the player to a new spot in the arena
+	Respawn
+)
+
+// GameMode decides the rules of a game: how players score, what happens to
+// players that fall into holes, who may spawn and when a match is won
+// The arena consults its mode while it holds its lock, so modes must not call
+// back into the arena
+type GameMode interface {
+	// Name identifies the mode
+	Name() string
+	// Spawn prepares a player that asked to spawn, such as by choosing its
+	// color, or returns an error if it may not spawn
+	Spawn(p *models.Player, players map[string]*models.Player) error
+	// JunkScored is called when junk last hit by scorer falls into a hole
+	JunkScored(scorer *models.Player)
+	// PlayerDied is called when a player falls into a hole, killer is the last
+	// player to hit it or nil
+	PlayerDied(victim *models.Player, killer *models.Player) Death
+	// Winner returns who won once the match is won, and false while it goes on
+	Winner(players map[string]*models.Player) (string, bool)
+	// Teams returns a copy of the mode's teams and their scores, or nil if
+	// players play for themselves
+	Teams() []models.Team
+	// Reset clears the mode's own scores before a new match
+	Reset()
+}
+
+// FreeForAllMode is the name of the mode every player plays for themselves in
+const FreeForAllMode = "freeForAll"
+
+// modes constructs each mode by name
+var modes = map[string]func() GameMode{
+	FreeForAllMode: func() GameMode { return FreeForAll{} },
+	TeamsMode:      func() GameMode { return CreateTeams(TeamCount) },
+}
+
+// CreateMode constructs the mode with the given name
+func CreateMode(name string) (GameMode, error) {
+	create, ok := modes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown game mode %q, expected one of %v", name, ModeNames())
+	}
+	return create(), nil
+}
+
+// ModeNames returns the names of every mode, sorted
+func ModeNames() []string {
+	names := make([]string, 0, len(modes))
+	for name := range modes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FreeForAll is the original game: every player plays for themselves, earns
+// points for pushing junk and other players into holes, and is eliminated when
+// it falls into one
+// Free-for-all games go on forever, so nobody ever wins
+type FreeForAll struct{}
+
+// Name returns FreeForAllMode
+func (FreeForAll) Name() string {
+	return FreeForAllMode
+}
+
+// Spawn lets every player spawn
+func (FreeForAll) Spawn(p *models.Player, players map[string]*models.Player) error {
+	return nil
+}
+
+// JunkScored awards PointsPerJunk to the scorer
+func (FreeForAll) JunkScored(scorer *models.Player) {
+	scorer.AddPoints(models.PointsPerJunk)
+}
+
+// PlayerDied awards PointsPerPlayer to the killer and eliminates the victim
+func (FreeForAll) PlayerDied(victim *models.Player, killer *models.Player) Death {
+	if killer != nil {
+		killer.AddPoints(models.PointsPerPlayer)
+		// database.SavePlayerScore(killer)
+	}
+	return Eliminate
+}
+
+// Winner never declares a winner
+func (FreeForAll) Winner(players map[string]*models.Player) (string, bool) {
+	return "", false
+}
+
+// Teams returns nil, every player plays for themselves
+func (FreeForAll) Teams() []models.Team {
+	return nil
+}
+
+// Reset does nothing, free-for-all keeps no scores of its own
+func (FreeForAll) Reset() {}
@@ -0,0 +1,87 @@
+package arena
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// testMode respawns players, counts scored junk and refuses players named "refused"
+type testMode struct {
+	FreeForAll
+	junkScored int
+}
+
+func (m *testMode) Spawn(p *models.Player, players map[string]*models.Player) error {
+	if p.Name == "refused" {
+		return errors.New("refused")
+	}
+	return nil
+}
+
+func (m *testMode) JunkScored(scorer *models.Player) {
+	m.junkScored++
+}
+
+func (m *testMode) PlayerDied(victim *models.Player, killer *models.Player) Death {
+	return Respawn
+}
+
+func TestModeSpawn(t *testing.T) {
+	a := CreateArena(testHeight, testWidth, 0, 0, nil)
+	a.Mode = &testMode{}
+	p, _ := a.AddPlayer(nil)
+
+	if err := a.SpawnPlayer(p.GetID(), "refused", "CA"); err == nil {
+		t.Fatal("Expected the mode to refuse the player")
+	}
+	if p.GetName() != "" {
+		t.Errorf("Expected a refused player to stay unspawned, got name %q", p.GetName())
+	}
+	if err := a.SpawnPlayer(p.GetID(), "testy", "CA"); err != nil {
+		t.Errorf("Expected the player to spawn after being refused, got %v", err)
+	}
+}
+
+func TestModeDeath(t *testing.T) {
+	a := CreateArena(testHeight, testWidth, 0, 0, nil)
+	mode := &testMode{}
+	a.Mode = mode
+
+	p, _ := a.AddPlayer(nil)
+	p.Position = quarterPosition
+	j := models.CreateJunk(quarterPosition)
+	j.LastPlayerHit = p
+	a.Junk = append(a.Junk, j)
+	h := models.CreateHole(quarterPosition)
+	h.IsAlive = true
+	a.Holes = append(a.Holes, h)
+
+	a.rebuildGrids()
+	a.holeCollisions()
+
+	if len(a.pending) != 0 {
+		t.Errorf("Expected the player to respawn instead of dying, got %s message", a.pending[0].Type)
+	}
+	if p.Position == quarterPosition {
+		t.Error("Expected the player to respawn away from the hole")
+	}
+	if mode.junkScored != 1 {
+		t.Errorf("Expected the mode to score the junk once, got %d", mode.junkScored)
+	}
+}
+
+func TestFreeForAllScoring(t *testing.T) {
+	killer := models.CreatePlayer("killer", "red", nil)
+	victim := models.CreatePlayer("victim", "blue", nil)
+	mode := FreeForAll{}
+
+	mode.JunkScored(killer)
+	if death := mode.PlayerDied(victim, killer); death != Eliminate {
+		t.Errorf("Expected the victim to be eliminated, got %d", death)
+	}
+	if want := models.PointsPerJunk + models.PointsPerPlayer; killer.Points != want {
+		t.Errorf("Expected the killer to have %d points, got %d", want, killer.Points)
+	}
+}
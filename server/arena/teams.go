@@ -0,0 +1,129 @@
+package arena
+
+import (
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// TeamsMode is the name of the mode players score for their team in
+const TeamsMode = "teams"
+
+// TeamCount is the number of teams new team games are played with, overridden
+// by the game configuration
+var TeamCount = 2
+
+// teamPalette names and colors the teams, in the order they are created
+var teamPalette = []models.Team{
+	{Name: "Red", Color: "#E74C3C"},
+	{Name: "Blue", Color: "#3498DB"},
+	{Name: "Green", Color: "#2ECC71"},
+	{Name: "Yellow", Color: "#F1C40F"},
+}
+
+// MaxTeams is the most teams a game can be played with, one per palette color
+var MaxTeams = len(teamPalette)
+
+// Teams splits players into teams that score together
+// Players join the team with the fewest spawned players and take its color
+// Pushing junk or an opponent into a hole scores for both the player and its
+// team, while pushing a teammate in costs both TeamKillPenalty points
+// Team games go on forever, so nobody ever wins
+type Teams struct {
+	teams []models.Team
+}
+
+// CreateTeams constructs a team game with count teams, between 2 and MaxTeams
+func CreateTeams(count int) *Teams {
+	if count < 2 {
+		count = 2
+	}
+	if count > MaxTeams {
+		count = MaxTeams
+	}
+
+	t := &Teams{teams: make([]models.Team, count)}
+	for i := range t.teams {
+		t.teams[i] = teamPalette[i]
+		t.teams[i].ID = i + 1
+	}
+	return t
+}
+
+// Name returns TeamsMode
+func (t *Teams) Name() string {
+	return TeamsMode
+}
+
+// Spawn puts the player on the team with the fewest spawned players, the
+// first such team if there is a tie
+func (t *Teams) Spawn(p *models.Player, players map[string]*models.Player) error {
+	sizes := make(map[int]int, len(t.teams))
+	for _, other := range players {
+		if other != p && other.Name != "" {
+			sizes[other.Team]++
+		}
+	}
+
+	team := &t.teams[0]
+	for i := range t.teams {
+		if sizes[t.teams[i].ID] < sizes[team.ID] {
+			team = &t.teams[i]
+		}
+	}
+
+	p.Team = team.ID
+	p.Color = team.Color
+	return nil
+}
+
+// JunkScored awards PointsPerJunk to the scorer and its team
+func (t *Teams) JunkScored(scorer *models.Player) {
+	t.award(scorer, models.PointsPerJunk)
+}
+
+// PlayerDied awards PointsPerPlayer to a killer from another team, or takes
+// TeamKillPenalty from a teammate, and eliminates the victim
+func (t *Teams) PlayerDied(victim *models.Player, killer *models.Player) Death {
+	if killer == nil {
+		return Eliminate
+	}
+
+	if killer.Team == victim.Team {
+		t.award(killer, -models.TeamKillPenalty)
+	} else {
+		t.award(killer, models.PointsPerPlayer)
+	}
+	return Eliminate
+}
+
+// Winner never declares a winner
+func (t *Teams) Winner(players map[string]*models.Player) (string, bool) {
+	return "", false
+}
+
+// Teams returns a copy of the teams and their scores
+func (t *Teams) Teams() []models.Team {
+	return append([]models.Team(nil), t.teams...)
+}
+
+// Reset clears every team's score, players stay on their teams
+func (t *Teams) Reset() {
+	for i := range t.teams {
+		t.teams[i].Points = 0
+	}
+}
+
+// award adds points to the player and its team
+func (t *Teams) award(p *models.Player, points int) {
+	p.AddPoints(points)
+	if team := t.team(p.Team); team != nil {
+		team.Points += points
+	}
+}
+
+// team returns the team with the given ID, or nil if there is none
+func (t *Teams) team(id int) *models.Team {
+	if id < 1 || id > len(t.teams) {
+		return nil
+	}
+	return &t.teams[id-1]
+}
@@ -0,0 +1,79 @@
+package arena
+
+import (
+	"testing"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+func TestTeamsSpawn(t *testing.T) {
+	testCases := []struct {
+		description string
+		teams       int
+		spawned     int
+		want        []int
+	}{
+		{"Two teams", 2, 5, []int{3, 2}},
+		{"Three teams", 3, 7, []int{3, 2, 2}},
+		{"Too many teams", 9, 8, []int{2, 2, 2, 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			a := CreateArena(testHeight, testWidth, 0, 0, nil)
+			a.Mode = CreateTeams(tc.teams)
+			for i := 0; i < tc.spawned; i++ {
+				p, _ := a.AddPlayer(nil)
+				if err := a.SpawnPlayer(p.GetID(), "testy", "CA"); err != nil {
+					t.Fatalf("Spawn failed: %v", err)
+				}
+			}
+
+			teams := a.GetTeams()
+			sizes := make([]int, len(teams))
+			for _, p := range a.Players {
+				team := teams[p.Team-1]
+				if p.Color != team.Color {
+					t.Errorf("Expected a player on team %s to be %s, got %s", team.Name, team.Color, p.Color)
+				}
+				sizes[p.Team-1]++
+			}
+			if len(sizes) != len(tc.want) {
+				t.Fatalf("Expected %d teams, got %d", len(tc.want), len(sizes))
+			}
+			for i := range sizes {
+				if sizes[i] != tc.want[i] {
+					t.Errorf("Expected team sizes %v, got %v", tc.want, sizes)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestTeamsScoring(t *testing.T) {
+	mode := CreateTeams(2)
+	killer := models.CreatePlayer("killer", "", nil)
+	teammate := models.CreatePlayer("teammate", "", nil)
+	opponent := models.CreatePlayer("opponent", "", nil)
+	killer.Team, teammate.Team, opponent.Team = 1, 1, 2
+
+	mode.JunkScored(killer)
+	if death := mode.PlayerDied(opponent, killer); death != Eliminate {
+		t.Errorf("Expected the opponent to be eliminated, got %d", death)
+	}
+	mode.PlayerDied(teammate, killer)
+	mode.PlayerDied(killer, nil)
+
+	want := models.PointsPerJunk + models.PointsPerPlayer - models.TeamKillPenalty
+	if killer.Points != want {
+		t.Errorf("Expected the killer to have %d points, got %d", want, killer.Points)
+	}
+	teams := mode.Teams()
+	if teams[0].Points != want {
+		t.Errorf("Expected the killer's team to have %d points, got %d", want, teams[0].Points)
+	}
+	if teams[1].Points != 0 {
+		t.Errorf("Expected the opponent's team to have no points, got %d", teams[1].Points)
+	}
+}
@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// Difficulty sets how quickly and accurately bots play
+type Difficulty string
+
+// Supported difficulties
+const (
+	Easy   Difficulty = "easy"
+	Normal Difficulty = "normal"
+	Hard   Difficulty = "hard"
+)
+
+// skill describes how bots of a difficulty play
+type skill struct {
+	reaction     float64 // seconds between decisions
+	aimTolerance float64 // radians a bot may be off target before it turns
+	holeMargin   float64 // distance beyond a hole's gravity field a bot keeps away from
+	bumpRange    float64 // distance within which a bot goes after other players
+}
+
+var skills = map[Difficulty]skill{
+	Easy:   {reaction: 0.5, aimTolerance: 0.5, holeMargin: 0, bumpRange: 0},
+	Normal: {reaction: 0.25, aimTolerance: 0.25, holeMargin: 50, bumpRange: 300},
+	Hard:   {reaction: 0.1, aimTolerance: 0.1, holeMargin: 100, bumpRange: 600},
+}
+
+// ParseDifficulty returns the difficulty with the given name
+func ParseDifficulty(name string) (Difficulty, error) {
+	d := Difficulty(name)
+	if _, ok := skills[d]; !ok {
+		return "", fmt.Errorf("unknown bot difficulty %q, expected easy, normal or hard", name)
+	}
+	return d, nil
+}
+
+// Bot steers a player without a connection, using the same key handlers as clients
+// Bots flee from the gravity of nearby holes, go after players within range
+// and otherwise chase the nearest junk
+type Bot struct {
+	Player  *models.Player
+	skill   skill
+	pressed map[int]bool
+}
+
+// CreateBot constructs a bot controlling the given player
+func CreateBot(p *models.Player, difficulty Difficulty) *Bot {
+	return &Bot{
+		Player:  p,
+		skill:   skills[difficulty],
+		pressed: make(map[int]bool),
+	}
+}
+
+// reactionTicks returns the number of ticks between the bot's decisions
+func (b *Bot) reactionTicks() uint64 {
+	return uint64(math.Max(1, math.Round(b.skill.reaction*models.TickRate)))
+}
+
+// Think picks a target from the state of the arena and presses the keys to move towards it
+func (b *Bot) Think(state *models.UpdateMessage) {
+	position := b.Player.GetPosition()
+
+	target, ok := b.escape(position, state.Holes)
+	if !ok {
+		target, ok = b.nearestPlayer(position, state.Players)
+	}
+	if !ok {
+		target, ok = nearestJunk(position, state.Junk)
+	}
+
+	b.steer(position, target, ok)
+}
+
+// escape returns a point directly away from the closest hole whose gravity the bot is too close to
+func (b *Bot) escape(position models.Position, holes []*models.Hole) (models.Position, bool) {
+	var closest *models.Hole
+	closestDistance := math.Inf(1)
+	for _, h := range holes {
+		if !h.IsAlive {
+			continue
+		}
+		d := distance(position, h.GetPosition())
+		if d < h.GetGravityRadius()+b.skill.holeMargin && d < closestDistance {
+			closest, closestDistance = h, d
+		}
+	}
+	if closest == nil {
+		return models.Position{}, false
+	}
+
+	hole := closest.GetPosition()
+	return models.Position{
+		X: 2*position.X - hole.X,
+		Y: 2*position.Y - hole.Y,
+	}, true
+}
+
+// nearestPlayer returns the position of the closest spawned player within bumping range
+func (b *Bot) nearestPlayer(position models.Position, players []*models.Player) (models.Position, bool) {
+	var target models.Position
+	found := false
+	closestDistance := b.skill.bumpRange
+	for _, p := range players {
+		if p == b.Player || p.GetName() == "" {
+			continue
+		}
+		if d := distance(position, p.GetPosition()); d < closestDistance {
+			target, found, closestDistance = p.GetPosition(), true, d
+		}
+	}
+	return target, found
+}
+
+// nearestJunk returns the position of the closest junk
+func nearestJunk(position models.Position, junk []*models.Junk) (models.Position, bool) {
+	var target models.Position
+	found := false
+	closestDistance := math.Inf(1)
+	for _, j := range junk {
+		if d := distance(position, j.GetPosition()); d < closestDistance {
+			target, found, closestDistance = j.GetPosition(), true, d
+		}
+	}
+	return target, found
+}
+
+// steer turns the player towards the target and accelerates once it roughly faces it
+// Players face the direction (sin(angle), cos(angle)) and turn left by increasing their angle
+func (b *Bot) steer(position models.Position, target models.Position, hasTarget bool) {
+	if !hasTarget {
+		b.press(models.LeftKey, false)
+		b.press(models.RightKey, false)
+		b.press(models.UpKey, false)
+		return
+	}
+
+	desired := math.Atan2(target.X-position.X, target.Y-position.Y)
+	offset := math.Remainder(desired-b.Player.Angle, 2*math.Pi)
+
+	b.press(models.LeftKey, offset > b.skill.aimTolerance)
+	b.press(models.RightKey, offset < -b.skill.aimTolerance)
+	b.press(models.UpKey, math.Abs(offset) < math.Pi/2)
+}
+
+// press holds down or releases a key, calling the player's key handlers only when it changes
+func (b *Bot) press(key int, down bool) {
+	if b.pressed[key] == down {
+		return
+	}
+	b.pressed[key] = down
+
+	if down {
+		b.Player.KeyDownHandler(key)
+	} else {
+		b.Player.KeyUpHandler(key)
+	}
+}
+
+func distance(p models.Position, q models.Position) float64 {
+	return math.Hypot(p.X-q.X, p.Y-q.Y)
+}
@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+const (
+	testHeight = 2400
+	testWidth  = 2800
+)
+
+var center = models.Position{X: testWidth / 2, Y: testHeight / 2}
+
+// createTestBot returns a hard bot facing straight down (towards positive y)
+func createTestBot() *Bot {
+	p := models.CreatePlayer("Bot", "white", nil)
+	p.Position = center
+	p.Angle = 0
+	return CreateBot(p, Hard)
+}
+
+func TestThink(t *testing.T) {
+	hole := models.CreateHole(models.Position{X: center.X, Y: center.Y + 50})
+	hole.IsAlive = true
+	infantHole := models.CreateHole(models.Position{X: center.X, Y: center.Y + 50})
+	below := models.CreateJunk(models.Position{X: center.X, Y: center.Y + 400})
+	diagonal := models.CreateJunk(models.Position{X: center.X + 400, Y: center.Y + 400})
+	other := models.CreatePlayer("Other", "red", nil)
+	other.Position = models.Position{X: center.X - 200, Y: center.Y + 200}
+
+	testCases := []struct {
+		description string
+		state       models.UpdateMessage
+		want        models.KeysPressed
+	}{
+		{"Chase junk ahead", models.UpdateMessage{Junk: []*models.Junk{below}}, models.KeysPressed{Up: true}},
+		{"Turn towards junk", models.UpdateMessage{Junk: []*models.Junk{diagonal}}, models.KeysPressed{Left: true, Up: true}},
+		{"Flee hole", models.UpdateMessage{Holes: []*models.Hole{hole}, Junk: []*models.Junk{below}}, models.KeysPressed{Left: true}},
+		{"Ignore infant hole", models.UpdateMessage{Holes: []*models.Hole{infantHole}, Junk: []*models.Junk{below}}, models.KeysPressed{Up: true}},
+		{"Bump nearby player", models.UpdateMessage{Junk: []*models.Junk{diagonal}, Players: []*models.Player{other}}, models.KeysPressed{Right: true, Up: true}},
+		{"Nothing to do", models.UpdateMessage{}, models.KeysPressed{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			b := createTestBot()
+			b.Player.Controls.Left = true // stale keys are released by the bot's next decision
+			b.pressed[models.LeftKey] = true
+			b.Think(&tc.state)
+
+			if b.Player.Controls != tc.want {
+				t.Errorf("Expected keys %+v, got %+v", tc.want, b.Player.Controls)
+			}
+		})
+	}
+}
+
+func TestBotReachesJunk(t *testing.T) {
+	b := createTestBot()
+	b.Player.Angle = math.Pi // facing away from the junk
+	junk := models.CreateJunk(models.Position{X: center.X + 300, Y: center.Y + 300})
+	state := &models.UpdateMessage{Junk: []*models.Junk{junk}}
+
+	for i := 0; i < 10*models.HzToSeconds; i++ {
+		b.Think(state)
+		b.Player.UpdatePosition(testHeight, testWidth)
+		if distance(b.Player.Position, junk.Position) < b.Player.GetRadius()+junk.GetRadius() {
+			return
+		}
+	}
+	t.Errorf("Bot did not reach junk within 10 seconds, ended at %v", b.Player.Position)
+}
+
+func TestParseDifficulty(t *testing.T) {
+	for _, name := range []string{"easy", "normal", "hard"} {
+		if _, err := ParseDifficulty(name); err != nil {
+			t.Errorf("Expected %q to be a difficulty, got %v", name, err)
+		}
+	}
+	if _, err := ParseDifficulty("impossible"); err == nil {
+		t.Error("Expected an error for an unknown difficulty")
+	}
+}
@@ -0,0 +1,124 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/ubclaunchpad/bumper/server/arena"
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// Manager keeps bots playing in an arena
+// Bots fill the arena up to Count, but give up their slots to humans so the
+// arena never holds more than Capacity players
+type Manager struct {
+	mutex      sync.Mutex
+	arena      *arena.Arena
+	count      int
+	difficulty Difficulty
+	capacity   int
+	bots       map[string]*Bot
+	names      int
+}
+
+// CreateManager constructs a manager for count bots of the given difficulty
+// A capacity of 0 leaves the number of players unbounded
+func CreateManager(a *arena.Arena, count int, difficulty Difficulty, capacity int) *Manager {
+	return &Manager{
+		arena:      a,
+		count:      count,
+		difficulty: difficulty,
+		capacity:   capacity,
+		bots:       make(map[string]*Bot),
+	}
+}
+
+// Configure changes the number and difficulty of bots
+// Bots that are already playing keep their difficulty
+func (m *Manager) Configure(count int, difficulty Difficulty) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.count = count
+	m.difficulty = difficulty
+}
+
+// SetCapacity changes the number of players bots make room for
+func (m *Manager) SetCapacity(capacity int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.capacity = capacity
+}
+
+// IsBot returns whether the player with the given ID is controlled by a bot
+func (m *Manager) IsBot(id string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, ok := m.bots[id]
+	return ok
+}
+
+// Update adds or removes bots to match the number of humans in the arena and
+// lets every bot whose reaction time has passed decide where to go next
+func (m *Manager) Update(tick uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// bots are removed from the arena when they die
+	for id := range m.bots {
+		if m.arena.GetPlayer(id) == nil {
+			delete(m.bots, id)
+		}
+	}
+
+	want := m.count
+	if m.capacity > 0 {
+		humans := len(m.arena.GetPlayers()) - len(m.bots)
+		if free := m.capacity - humans; free < want {
+			want = free
+		}
+	}
+
+	for id, b := range m.bots {
+		if len(m.bots) <= want {
+			break
+		}
+		m.arena.RemovePlayer(b.Player)
+		delete(m.bots, id)
+	}
+	for len(m.bots) < want {
+		if !m.add() {
+			break
+		}
+	}
+
+	state := m.arena.GetState()
+	for _, b := range m.bots {
+		if tick%b.reactionTicks() == 0 {
+			b.Think(state)
+		}
+	}
+}
+
+// add spawns a new bot, returning false if the arena has no room for it
+func (m *Manager) add() bool {
+	p, err := m.arena.AddPlayer(models.NoopConnection{})
+	if err != nil {
+		log.Printf("Error adding bot:\n%v", err)
+		return false
+	}
+
+	m.names++
+	err = m.arena.SpawnPlayer(p.GetID(), fmt.Sprintf("Bot %d", m.names), "")
+	if err != nil {
+		log.Printf("Error spawning bot:\n%v", err)
+		m.arena.RemovePlayer(p)
+		return false
+	}
+
+	m.bots[p.GetID()] = CreateBot(p, m.difficulty)
+	return true
+}
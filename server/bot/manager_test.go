@@ -0,0 +1,61 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/ubclaunchpad/bumper/server/arena"
+)
+
+func countBots(m *Manager, a *arena.Arena) int {
+	bots := 0
+	for _, p := range a.GetPlayers() {
+		if m.IsBot(p.GetID()) {
+			bots++
+		}
+	}
+	return bots
+}
+
+func TestManagerYieldsToHumans(t *testing.T) {
+	a := arena.CreateArena(testHeight, testWidth, 0, 10, nil)
+	m := CreateManager(a, 3, Normal, 4)
+
+	m.Update(1)
+	if got := countBots(m, a); got != 3 {
+		t.Fatalf("Expected 3 bots in an empty arena, got %d", got)
+	}
+
+	human, _ := a.AddPlayer(nil)
+	a.AddPlayer(nil)
+	m.Update(2)
+	if got := countBots(m, a); got != 2 {
+		t.Errorf("Expected bots to make room for 2 humans, got %d bots", got)
+	}
+
+	a.RemovePlayer(human)
+	m.Update(3)
+	if got := countBots(m, a); got != 3 {
+		t.Errorf("Expected bots to return once a human left, got %d bots", got)
+	}
+}
+
+func TestManagerReplacesDeadBots(t *testing.T) {
+	a := arena.CreateArena(testHeight, testWidth, 0, 10, nil)
+	m := CreateManager(a, 2, Easy, 0)
+	m.Update(1)
+
+	for _, p := range a.GetPlayers() {
+		a.RemovePlayer(p)
+		break
+	}
+	m.Update(2)
+	if got := countBots(m, a); got != 2 {
+		t.Errorf("Expected a dead bot to be replaced, got %d bots", got)
+	}
+
+	m.Configure(0, Easy)
+	m.Update(3)
+	if got := len(a.GetPlayers()); got != 0 {
+		t.Errorf("Expected every bot to leave, got %d players", got)
+	}
+}
@@ -0,0 +1,542 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ubclaunchpad/bumper/server/arena"
+	"github.com/ubclaunchpad/bumper/server/bot"
+	"github.com/ubclaunchpad/bumper/server/game"
+	"github.com/ubclaunchpad/bumper/server/lobby"
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a setting
+// A setting's variable is its JSON path in upper snake case, such as
+// BUMPER_ARENA_WIDTH for arena.width or BUMPER_PLAYER_MAX_VELOCITY for player.maxVelocity
+const EnvPrefix = "BUMPER"
+
+// Config holds every setting a designer can tune without recompiling
+type Config struct {
+	Arena      Arena      `json:"arena"`
+	TickRate   float64    `json:"tickRate"`
+	Scoring    Scoring    `json:"scoring"`
+	Player     Player     `json:"player"`
+	Junk       Junk       `json:"junk"`
+	Hole       Hole       `json:"hole"`
+	PowerUps   PowerUps   `json:"powerUps"`
+	Bots       Bots       `json:"bots"`
+	Match      Match      `json:"match"`
+	Snapshots  Snapshots  `json:"snapshots"`
+	Connection Connection `json:"connection"`
+	Server     Server     `json:"server"`
+}
+
+// Arena sets the size of new arenas, the objects placed in them and the game
+// mode new rooms play unless clients ask for another
+// Teams is the number of teams in team games
+type Arena struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Holes  int     `json:"holes"`
+	Junk   int     `json:"junk"`
+	Mode   string  `json:"mode"`
+	Teams  int     `json:"teams"`
+}
+
+// Scoring sets the points awarded for pushing objects into holes, and the
+// points lost for pushing a teammate into one
+// Debounces are measured in ticks at 60 Hz
+type Scoring struct {
+	PointsPerJunk       int `json:"pointsPerJunk"`
+	PointsPerPlayer     int `json:"pointsPerPlayer"`
+	TeamKillPenalty     int `json:"teamKillPenalty"`
+	PointsDebounceTicks int `json:"pointsDebounceTicks"`
+}
+
+// Player sets player physics
+// Per tick values are tuned for 60 Hz and scaled to the tick rate
+type Player struct {
+	Radius           float64 `json:"radius"`
+	Mass             float64 `json:"mass"`
+	Restitution      float64 `json:"restitution"`
+	Acceleration     float64 `json:"acceleration"`
+	Friction         float64 `json:"friction"`
+	MaxVelocity      float64 `json:"maxVelocity"`
+	GravityDamping   float64 `json:"gravityDamping"`
+	WallBounceFactor float64 `json:"wallBounceFactor"`
+	DebounceTicks    int     `json:"debounceTicks"`
+}
+
+// Junk sets junk physics
+// Per tick values are tuned for 60 Hz and scaled to the tick rate
+type Junk struct {
+	Radius         float64 `json:"radius"`
+	Mass           float64 `json:"mass"`
+	Restitution    float64 `json:"restitution"`
+	Friction       float64 `json:"friction"`
+	GravityDamping float64 `json:"gravityDamping"`
+	DebounceTicks  int     `json:"debounceTicks"`
+}
+
+// Hole sets the size and lifetime of holes, lifetimes are measured in seconds
+type Hole struct {
+	MinRadius           float64 `json:"minRadius"`
+	MaxRadius           float64 `json:"maxRadius"`
+	GravityRadiusFactor float64 `json:"gravityRadiusFactor"`
+	MinLife             float64 `json:"minLife"`
+	MaxLife             float64 `json:"maxLife"`
+	Infancy             float64 `json:"infancy"`
+}
+
+// PowerUps sets how often power-ups appear and what their effects do
+// Intervals and durations are measured in seconds
+type PowerUps struct {
+	Interval         float64 `json:"interval"`
+	Max              int     `json:"max"`
+	Radius           float64 `json:"radius"`
+	EffectDuration   float64 `json:"effectDuration"`
+	SpeedBoostFactor float64 `json:"speedBoostFactor"`
+	HeavyMassFactor  float64 `json:"heavyMassFactor"`
+	MagnetRadius     float64 `json:"magnetRadius"`
+	MagnetStrength   float64 `json:"magnetStrength"`
+}
+
+// Bots sets the bots that play in every new room
+// Difficulty is easy, normal or hard
+type Bots struct {
+	Count      int    `json:"count"`
+	Difficulty string `json:"difficulty"`
+}
+
+// Match sets how long each phase of a match lasts, in seconds, and how many
+// spawned players a match needs to start
+// A duration of 0, the default, lets games run forever without matches
+type Match struct {
+	Duration   float64 `json:"duration"`
+	Warmup     float64 `json:"warmup"`
+	Countdown  float64 `json:"countdown"`
+	Results    float64 `json:"results"`
+	MinPlayers int     `json:"minPlayers"`
+}
+
+// Snapshots sets how often clients are sent keyframes, in seconds, the radius
+// around their player they get every update in, and how many times a second
+// they are sent objects outside it
+// A keyframeInterval or farUpdateRate of 0 turns periodic keyframes or the interest radius off
+type Snapshots struct {
+	KeyframeInterval float64 `json:"keyframeInterval"`
+	InterestRadius   float64 `json:"interestRadius"`
+	FarUpdateRate    float64 `json:"farUpdateRate"`
+}
+
+// Connection sets how unresponsive and idle clients are detected, in seconds
+// An afkTimeout of 0 lets spawned players idle forever
+type Connection struct {
+	PingInterval float64 `json:"pingInterval"`
+	PongTimeout  float64 `json:"pongTimeout"`
+	WriteTimeout float64 `json:"writeTimeout"`
+	AFKTimeout   float64 `json:"afkTimeout"`
+}
+
+// Server sets who may connect to the server
+// allowedOrigins lists the origins of pages clients may connect from, "*"
+// allows any origin, and limits of 0 leave the number of connections unbounded
+type Server struct {
+	AllowedOrigins      []string `json:"allowedOrigins"`
+	MaxPlayers          int      `json:"maxPlayers"`
+	MaxConnectionsPerIP int      `json:"maxConnectionsPerIP"`
+}
+
+// Default returns the settings the game was originally tuned with
+func Default() *Config {
+	return &Config{
+		Arena: Arena{
+			Width:  2800,
+			Height: 2400,
+			Holes:  20,
+			Junk:   30,
+			Mode:   arena.FreeForAllMode,
+			Teams:  2,
+		},
+		TickRate: models.BaseTickRate,
+		Scoring: Scoring{
+			PointsPerJunk:       100,
+			PointsPerPlayer:     500,
+			TeamKillPenalty:     500,
+			PointsDebounceTicks: 100,
+		},
+		Player: Player{
+			Radius:           25,
+			Mass:             1,
+			Restitution:      0.9,
+			Acceleration:     0.5,
+			Friction:         0.97,
+			MaxVelocity:      15,
+			GravityDamping:   0.075,
+			WallBounceFactor: -1.5,
+			DebounceTicks:    15,
+		},
+		Junk: Junk{
+			Radius:         11,
+			Mass:           0.25,
+			Restitution:    0.5,
+			Friction:       0.99,
+			GravityDamping: 0.025,
+			DebounceTicks:  15,
+		},
+		Hole: Hole{
+			MinRadius:           15,
+			MaxRadius:           45,
+			GravityRadiusFactor: 5,
+			MinLife:             25,
+			MaxLife:             75,
+			Infancy:             2,
+		},
+		PowerUps: PowerUps{
+			Interval:         10,
+			Max:              5,
+			Radius:           15,
+			EffectDuration:   10,
+			SpeedBoostFactor: 1.5,
+			HeavyMassFactor:  3,
+			MagnetRadius:     250,
+			MagnetStrength:   0.3,
+		},
+		Bots: Bots{
+			Count:      0,
+			Difficulty: string(bot.Normal),
+		},
+		Match: Match{
+			Duration:   0,
+			Warmup:     30,
+			Countdown:  5,
+			Results:    10,
+			MinPlayers: 2,
+		},
+		Snapshots: Snapshots{
+			KeyframeInterval: 2,
+			InterestRadius:   1200,
+			FarUpdateRate:    6,
+		},
+		Connection: Connection{
+			PingInterval: 20,
+			PongTimeout:  45,
+			WriteTimeout: 10,
+			AFKTimeout:   120,
+		},
+		Server: Server{
+			AllowedOrigins:      []string{"*"},
+			MaxPlayers:          300,
+			MaxConnectionsPerIP: 10,
+		},
+	}
+}
+
+// Load reads the default settings overridden by the JSON file at path, if
+// path is not empty, and then by environment variables
+// The resulting settings are validated before they are returned
+func Load(path string) (*Config, error) {
+	c := Default()
+
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config: %v", err)
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config %s: %v", path, err)
+		}
+	}
+
+	err := overrideFromEnv(reflect.ValueOf(c).Elem(), EnvPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate returns an error listing every setting that is out of range
+func (c *Config) Validate() error {
+	v := validator{}
+
+	v.positive("arena.width", c.Arena.Width)
+	v.positive("arena.height", c.Arena.Height)
+	v.nonNegative("arena.holes", float64(c.Arena.Holes))
+	v.nonNegative("arena.junk", float64(c.Arena.Junk))
+	if _, err := arena.CreateMode(c.Arena.Mode); err != nil {
+		v.problems = append(v.problems, "arena.mode: "+err.Error())
+	}
+	v.between("arena.teams", float64(c.Arena.Teams), 2, float64(arena.MaxTeams))
+	v.positive("tickRate", c.TickRate)
+
+	v.nonNegative("scoring.pointsPerJunk", float64(c.Scoring.PointsPerJunk))
+	v.nonNegative("scoring.pointsPerPlayer", float64(c.Scoring.PointsPerPlayer))
+	v.nonNegative("scoring.teamKillPenalty", float64(c.Scoring.TeamKillPenalty))
+	v.nonNegative("scoring.pointsDebounceTicks", float64(c.Scoring.PointsDebounceTicks))
+
+	v.positive("player.radius", c.Player.Radius)
+	v.positive("player.mass", c.Player.Mass)
+	v.fraction("player.restitution", c.Player.Restitution)
+	v.nonNegative("player.acceleration", c.Player.Acceleration)
+	v.fraction("player.friction", c.Player.Friction)
+	v.positive("player.maxVelocity", c.Player.MaxVelocity)
+	v.nonNegative("player.gravityDamping", c.Player.GravityDamping)
+	// players are sent back from the walls, at most twice as fast as they hit them
+	v.between("player.wallBounceFactor", c.Player.WallBounceFactor, -2, 0)
+	v.nonNegative("player.debounceTicks", float64(c.Player.DebounceTicks))
+
+	v.positive("junk.radius", c.Junk.Radius)
+	v.positive("junk.mass", c.Junk.Mass)
+	v.fraction("junk.restitution", c.Junk.Restitution)
+	v.fraction("junk.friction", c.Junk.Friction)
+	v.nonNegative("junk.gravityDamping", c.Junk.GravityDamping)
+	v.nonNegative("junk.debounceTicks", float64(c.Junk.DebounceTicks))
+
+	v.positive("hole.minRadius", c.Hole.MinRadius)
+	v.atLeast("hole.maxRadius", c.Hole.MaxRadius, "hole.minRadius", c.Hole.MinRadius)
+	v.positive("hole.gravityRadiusFactor", c.Hole.GravityRadiusFactor)
+	v.positive("hole.minLife", c.Hole.MinLife)
+	v.atLeast("hole.maxLife", c.Hole.MaxLife, "hole.minLife", c.Hole.MinLife)
+	v.nonNegative("hole.infancy", c.Hole.Infancy)
+
+	v.positive("powerUps.interval", c.PowerUps.Interval)
+	v.nonNegative("powerUps.max", float64(c.PowerUps.Max))
+	v.positive("powerUps.radius", c.PowerUps.Radius)
+	v.positive("powerUps.effectDuration", c.PowerUps.EffectDuration)
+	v.notBelow("powerUps.speedBoostFactor", c.PowerUps.SpeedBoostFactor, 1)
+	v.notBelow("powerUps.heavyMassFactor", c.PowerUps.HeavyMassFactor, 1)
+	v.nonNegative("powerUps.magnetRadius", c.PowerUps.MagnetRadius)
+	v.nonNegative("powerUps.magnetStrength", c.PowerUps.MagnetStrength)
+
+	v.nonNegative("bots.count", float64(c.Bots.Count))
+	if _, err := bot.ParseDifficulty(c.Bots.Difficulty); err != nil {
+		v.problems = append(v.problems, "bots.difficulty: "+err.Error())
+	}
+
+	v.nonNegative("match.duration", c.Match.Duration)
+	v.nonNegative("match.warmup", c.Match.Warmup)
+	v.nonNegative("match.countdown", c.Match.Countdown)
+	v.nonNegative("match.results", c.Match.Results)
+	v.positive("match.minPlayers", float64(c.Match.MinPlayers))
+
+	v.nonNegative("snapshots.keyframeInterval", c.Snapshots.KeyframeInterval)
+	v.positive("snapshots.interestRadius", c.Snapshots.InterestRadius)
+	v.nonNegative("snapshots.farUpdateRate", c.Snapshots.FarUpdateRate)
+
+	v.positive("connection.pingInterval", c.Connection.PingInterval)
+	v.atLeast("connection.pongTimeout", c.Connection.PongTimeout, "connection.pingInterval", c.Connection.PingInterval)
+	v.positive("connection.writeTimeout", c.Connection.WriteTimeout)
+	v.nonNegative("connection.afkTimeout", c.Connection.AFKTimeout)
+
+	for _, origin := range c.Server.AllowedOrigins {
+		if u, err := url.Parse(origin); origin != "*" && (err != nil || u.Scheme == "" || u.Host == "") {
+			v.problems = append(v.problems, fmt.Sprintf("server.allowedOrigins: %q is not * or an origin like https://example.com", origin))
+		}
+	}
+	v.nonNegative("server.maxPlayers", float64(c.Server.MaxPlayers))
+	v.nonNegative("server.maxConnectionsPerIP", float64(c.Server.MaxConnectionsPerIP))
+
+	if len(v.problems) > 0 {
+		return fmt.Errorf("invalid config:\n\t%s", strings.Join(v.problems, "\n\t"))
+	}
+	return nil
+}
+
+// Apply sets the settings used by every game, it must be called before any game is created
+func (c *Config) Apply() {
+	models.TickRate = c.TickRate
+	game.KeyframeInterval = seconds(c.Snapshots.KeyframeInterval)
+	game.InterestRadius = c.Snapshots.InterestRadius
+	game.FarUpdateRate = c.Snapshots.FarUpdateRate
+	models.PingInterval = seconds(c.Connection.PingInterval)
+	models.PongTimeout = seconds(c.Connection.PongTimeout)
+	models.WriteTimeout = seconds(c.Connection.WriteTimeout)
+	game.AFKTimeout = seconds(c.Connection.AFKTimeout)
+	game.AllowedOrigins = c.Server.AllowedOrigins
+	lobby.MaxPlayers = c.Server.MaxPlayers
+	lobby.MaxConnectionsPerIP = c.Server.MaxConnectionsPerIP
+	c.ApplyRules()
+}
+
+// ApplyRules sets every setting except the tick rate, snapshot, connection and
+// server settings, which running games depend on
+// Use game.ApplyRules to change the rules while games are running
+// Hole, junk, mode and bot settings only take effect in games created
+// afterwards, except that holes and junk are also applied when a match resets the arena
+func (c *Config) ApplyRules() {
+	game.ArenaWidth = c.Arena.Width
+	game.ArenaHeight = c.Arena.Height
+	game.HoleCount = c.Arena.Holes
+	game.JunkCount = c.Arena.Junk
+	game.DefaultMode = c.Arena.Mode
+	arena.TeamCount = c.Arena.Teams
+	game.BotCount = c.Bots.Count
+	game.BotDifficulty = bot.Difficulty(c.Bots.Difficulty)
+	game.MatchDuration = seconds(c.Match.Duration)
+	game.WarmupDuration = seconds(c.Match.Warmup)
+	game.CountdownDuration = seconds(c.Match.Countdown)
+	game.ResultsDuration = seconds(c.Match.Results)
+	game.MatchMinPlayers = c.Match.MinPlayers
+
+	models.PointsPerJunk = c.Scoring.PointsPerJunk
+	models.PointsPerPlayer = c.Scoring.PointsPerPlayer
+	models.TeamKillPenalty = c.Scoring.TeamKillPenalty
+	models.PointsDebounceTicks = c.Scoring.PointsDebounceTicks
+
+	models.PlayerRadius = c.Player.Radius
+	models.PlayerMass = c.Player.Mass
+	models.PlayerRestitution = c.Player.Restitution
+	models.PlayerAcceleration = c.Player.Acceleration
+	models.PlayerFriction = c.Player.Friction
+	models.MaxVelocity = c.Player.MaxVelocity
+	models.PlayerGravityDamping = c.Player.GravityDamping
+	models.WallBounceFactor = c.Player.WallBounceFactor
+	models.PlayerDebounceTicks = c.Player.DebounceTicks
+
+	models.JunkRadius = c.Junk.Radius
+	models.JunkFriction = c.Junk.Friction
+	models.JunkMass = c.Junk.Mass
+	models.JunkRestitution = c.Junk.Restitution
+	models.JunkGravityDamping = c.Junk.GravityDamping
+	models.JunkDebounceTicks = c.Junk.DebounceTicks
+
+	models.MinHoleRadius = c.Hole.MinRadius
+	models.MaxHoleRadius = c.Hole.MaxRadius
+	models.GravityRadiusFactor = c.Hole.GravityRadiusFactor
+	models.MinHoleLife = c.Hole.MinLife * models.HzToSeconds
+	models.MaxHoleLife = c.Hole.MaxLife * models.HzToSeconds
+	models.HoleInfancy = c.Hole.Infancy * models.HzToSeconds
+
+	models.PowerUpInterval = c.PowerUps.Interval
+	models.MaxPowerUps = c.PowerUps.Max
+	models.PowerUpRadius = c.PowerUps.Radius
+	models.EffectDuration = c.PowerUps.EffectDuration
+	models.SpeedBoostFactor = c.PowerUps.SpeedBoostFactor
+	models.HeavyMassFactor = c.PowerUps.HeavyMassFactor
+	models.MagnetRadius = c.PowerUps.MagnetRadius
+	models.MagnetStrength = c.PowerUps.MagnetStrength
+}
+
+// seconds converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// overrideFromEnv sets every field of the struct v from the environment
+// variable named after its JSON path, if that variable is set
+// Lists are given as comma separated values
+func overrideFromEnv(v reflect.Value, prefix string) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		name := prefix + "_" + envName(v.Type().Field(i).Tag.Get("json"))
+
+		if field.Kind() == reflect.Struct {
+			err := overrideFromEnv(field, name)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.Float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for %s: expected a number", value, name)
+			}
+			field.SetFloat(f)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for %s: expected an integer", value, name)
+			}
+			field.SetInt(int64(n))
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Slice:
+			var values []string
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					values = append(values, v)
+				}
+			}
+			field.Set(reflect.ValueOf(values))
+		}
+	}
+	return nil
+}
+
+// envName converts a camel case JSON name to upper snake case
+func envName(jsonName string) string {
+	var b strings.Builder
+	for i, r := range jsonName {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// validator collects a description of every invalid setting
+type validator struct {
+	problems []string
+}
+
+func (v *validator) positive(name string, value float64) {
+	if value <= 0 {
+		v.problems = append(v.problems, fmt.Sprintf("%s must be greater than 0, got %g", name, value))
+	}
+}
+
+func (v *validator) nonNegative(name string, value float64) {
+	if value < 0 {
+		v.problems = append(v.problems, fmt.Sprintf("%s must not be negative, got %g", name, value))
+	}
+}
+
+func (v *validator) fraction(name string, value float64) {
+	v.between(name, value, 0, 1)
+}
+
+func (v *validator) between(name string, value float64, min float64, max float64) {
+	if value < min || value > max {
+		v.problems = append(v.problems, fmt.Sprintf("%s must be between %g and %g, got %g", name, min, max, value))
+	}
+}
+
+func (v *validator) notBelow(name string, value float64, min float64) {
+	if value < min {
+		v.problems = append(v.problems, fmt.Sprintf("%s must be at least %g, got %g", name, min, value))
+	}
+}
+
+func (v *validator) atLeast(name string, value float64, otherName string, other float64) {
+	if value < other {
+		v.problems = append(v.problems, fmt.Sprintf("%s must be at least %s (%g), got %g", name, otherName, other, value))
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes contents to a config file in a temporary directory
+func writeConfig(t *testing.T, contents string) string {
+	dir, err := ioutil.TempDir("", "bumper-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "config.json")
+	err = ioutil.WriteFile(path, []byte(contents), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	err := Default().Validate()
+	if err != nil {
+		t.Errorf("Default config is invalid: %v", err)
+	}
+}
+
+func TestExampleMatchesDefault(t *testing.T) {
+	c, err := Load("../config.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, Default()) {
+		t.Errorf("Expected config.example.json to contain the default settings, got %+v", c)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `{"arena": {"width": 1000}, "player": {"maxVelocity": 20}}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	os.Setenv("BUMPER_PLAYER_MAX_VELOCITY", "25")
+	os.Setenv("BUMPER_TICK_RATE", "30")
+	os.Setenv("BUMPER_BOTS_DIFFICULTY", "hard")
+	os.Setenv("BUMPER_SERVER_ALLOWED_ORIGINS", "https://bumper.example, http://localhost:8080")
+	defer os.Unsetenv("BUMPER_PLAYER_MAX_VELOCITY")
+	defer os.Unsetenv("BUMPER_TICK_RATE")
+	defer os.Unsetenv("BUMPER_BOTS_DIFFICULTY")
+	defer os.Unsetenv("BUMPER_SERVER_ALLOWED_ORIGINS")
+
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Arena.Width != 1000 {
+		t.Errorf("Expected arena width from file, got %g", c.Arena.Width)
+	}
+	if c.Arena.Height != Default().Arena.Height {
+		t.Errorf("Expected default arena height, got %g", c.Arena.Height)
+	}
+	if c.Player.MaxVelocity != 25 {
+		t.Errorf("Expected environment to override file, got max velocity %g", c.Player.MaxVelocity)
+	}
+	if c.TickRate != 30 {
+		t.Errorf("Expected tick rate from environment, got %g", c.TickRate)
+	}
+	if c.Bots.Difficulty != "hard" {
+		t.Errorf("Expected bot difficulty from environment, got %q", c.Bots.Difficulty)
+	}
+	origins := []string{"https://bumper.example", "http://localhost:8080"}
+	if !reflect.DeepEqual(c.Server.AllowedOrigins, origins) {
+		t.Errorf("Expected allowed origins %v from environment, got %v", origins, c.Server.AllowedOrigins)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	testCases := []struct {
+		description string
+		contents    string
+		env         map[string]string
+		want        []string
+	}{
+		{"Unknown setting", `{"arena": {"widht": 1000}}`, nil, []string{"widht"}},
+		{"Malformed file", `{"arena": `, nil, []string{"parsing config"}},
+		{"Invalid environment value", `{}`, map[string]string{"BUMPER_ARENA_HOLES": "many"}, []string{"BUMPER_ARENA_HOLES"}},
+		{
+			"Out of range settings",
+			`{"tickRate": 0, "player": {"friction": 1.5}, "hole": {"minLife": 30, "maxLife": 10}, "bots": {"difficulty": "impossible"}}`,
+			nil,
+			[]string{"tickRate must be greater than 0", "player.friction must be between 0 and 1", "hole.maxLife must be at least hole.minLife", "bots.difficulty"},
+		},
+		{
+			"Walls that push players out",
+			`{"player": {"wallBounceFactor": 1.5}}`,
+			nil,
+			[]string{"player.wallBounceFactor must be between -2 and 0"},
+		},
+		{
+			"Massless bouncy junk",
+			`{"junk": {"mass": 0, "restitution": 2}}`,
+			nil,
+			[]string{"junk.mass must be greater than 0", "junk.restitution must be between 0 and 1"},
+		},
+		{
+			"Pong timeout shorter than ping interval",
+			`{"connection": {"pingInterval": 30, "pongTimeout": 10}}`,
+			nil,
+			[]string{"connection.pongTimeout must be at least connection.pingInterval"},
+		},
+		{
+			"Too many teams",
+			`{"arena": {"mode": "teams", "teams": 5}}`,
+			nil,
+			[]string{"arena.teams must be between 2 and 4"},
+		},
+		{
+			"Origin without a scheme",
+			`{"server": {"allowedOrigins": ["bumper.example"]}}`,
+			nil,
+			[]string{"server.allowedOrigins"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := writeConfig(t, tc.contents)
+			defer os.RemoveAll(filepath.Dir(path))
+			for name, value := range tc.env {
+				os.Setenv(name, value)
+				defer os.Unsetenv(name)
+			}
+
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to mention %q, got:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	testCases := []struct {
+		jsonName string
+		want     string
+	}{
+		{"width", "WIDTH"},
+		{"tickRate", "TICK_RATE"},
+		{"gravityDamping", "GRAVITY_DAMPING"},
+		{"afkTimeout", "AFK_TIMEOUT"},
+	}
+
+	for _, tc := range testCases {
+		if got := envName(tc.jsonName); got != tc.want {
+			t.Errorf("envName(%q) = %q; want %q", tc.jsonName, got, tc.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"sync"
 
 	"github.com/ubclaunchpad/bumper/server/models"
 
@@ -21,6 +22,9 @@ type LeaderboardEntry struct {
 // DBC is a connection handle to the firebase database
 var DBC *db.Client
 
+// pending tracks leaderboard writes started by SavePlayerScore that have not completed
+var pending sync.WaitGroup
+
 // ConnectDB connects the DB handle to firebase db.
 func ConnectDB(credentialsPath string) {
 	if _, err := os.Stat(credentialsPath); os.IsNotExist(err) {
@@ -66,6 +70,32 @@ func UpdatePlayerScore(p *models.Player) {
 	}
 }
 
+// SavePlayerScore updates the score for the given player in the background
+// Call Flush before exiting to wait for the write to complete
+func SavePlayerScore(p *models.Player) {
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		UpdatePlayerScore(p)
+	}()
+}
+
+// Flush waits for pending leaderboard writes to complete or for ctx to be done
+func Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // FetchPlayerScore retreives the score for the given player
 func FetchPlayerScore(p *models.Player) *LeaderboardEntry {
 	if DBC == nil {
@@ -1,104 +1,325 @@
 package game
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/ubclaunchpad/bumper/server/arena"
+	"github.com/ubclaunchpad/bumper/server/bot"
 	"github.com/ubclaunchpad/bumper/server/models"
 )
 
 // An instance of Upgrader that upgrades a connection to a WebSocket
+// Clients pick the JSON or binary encoding through the WebSocket subprotocol
 var upgrader = websocket.Upgrader{
+	Subprotocols: models.Subprotocols,
 	CheckOrigin: func(r *http.Request) bool {
+		if !OriginAllowed(r) {
+			log.Printf("Refusing client from remote address %v with origin %s\n", r.RemoteAddr, r.Header.Get("Origin"))
+			return false
+		}
 		log.Printf("Accepting client from remote address %v\n", r.RemoteAddr)
 		return true
 	},
 }
 
+// AllowedOrigins are the origins of the pages clients may connect from, "*"
+// allows any origin
+// It is set by the game configuration before the server starts
+var AllowedOrigins = []string{"*"}
+
+// OriginAllowed returns whether the request comes from a page in AllowedOrigins
+// Pages served by this server and requests from outside browsers, which have
+// no Origin header, are always allowed
+func OriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, allowed := range AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
 // Game represents a session
+// The simulation is advanced in fixed steps of RefreshRate and every step
+// increments Tick, which stamps the snapshots broadcast to clients
 type Game struct {
 	Arena       *arena.Arena
 	RefreshRate time.Duration
+	Tick        uint64
+	Snapshots   *Snapshots
+	Bots        *bot.Manager
+	Sessions    *Sessions
+	Bans        *Bans
+	Match       *Match
+	events      chan models.Message
+	ctx         context.Context
+	cancel      context.CancelFunc
+	done        chan struct{}
 }
 
+// Game related constants
+const (
+	eventBufferSize  = 64
+	maxStepsPerFrame = 5
+)
+
+// Arena settings for new games, overridden by the game configuration
+var (
+	ArenaWidth  = 2800.0
+	ArenaHeight = 2400.0
+	HoleCount   = 20
+	JunkCount   = 30
+)
+
+// Bot settings for new games, overridden by the game configuration
+var (
+	BotCount      = 0
+	BotDifficulty = bot.Normal
+)
+
+// DefaultMode is the name of the mode new games play when none is requested,
+// overridden by the game configuration
+var DefaultMode = arena.FreeForAllMode
+
+// AFKTimeout is how long a spawned player may go without input before it is
+// removed from the arena, 0 lets players idle forever
+// It is set by the game configuration before any game starts
+var AFKTimeout = 2 * time.Minute
+
+// rulesMutex guards the arena settings and the physics and scoring settings
+// in models, which games read while they step
+var rulesMutex sync.RWMutex
+
 // CreateGame constructor initializes arena and refresh rate
-func CreateGame() *Game {
-	g := Game{
-		Arena:       arena.CreateArena(2400, 2800, 20, 30),
-		RefreshRate: time.Millisecond * 17, // 60 Hz
+// Each game owns the channel its arena emits events on, so several games can
+// run in the same process without receiving each other's events
+// The game plays the mode with the given name, or DefaultMode if it is empty
+func CreateGame(mode string) (*Game, error) {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+
+	if mode == "" {
+		mode = DefaultMode
+	}
+	m, err := arena.CreateMode(mode)
+	if err != nil {
+		return nil, err
 	}
-	return &g
+
+	// the game has a context before it starts, so events can be emitted to it
+	// while it is set up, it is replaced once the game starts
+	ctx, cancel := context.WithCancel(context.Background())
+	g := &Game{
+		ctx:         ctx,
+		cancel:      cancel,
+		RefreshRate: time.Duration(float64(time.Second) / models.TickRate),
+		Snapshots:   CreateSnapshots(),
+		Sessions:    CreateSessions(),
+		Bans:        CreateBans(),
+		Match:       CreateMatch(),
+		events:      make(chan models.Message, eventBufferSize),
+		done:        make(chan struct{}),
+	}
+	g.Arena = arena.CreateArena(ArenaHeight, ArenaWidth, HoleCount, JunkCount, g.Emit)
+	g.Arena.Mode = m
+	g.Bots = bot.CreateManager(g.Arena, BotCount, BotDifficulty, 0)
+	return g, nil
 }
 
 // StartGame runs goroutines required to start a session
-func (g *Game) StartGame() {
-	go g.messageEmitter()
-	go g.run()
-	go g.tick()
+// The session ends when ctx is cancelled or StopGame is called, after which
+// every player is told the server is shutting down and disconnected
+func (g *Game) StartGame(ctx context.Context) {
+	g.ctx, g.cancel = context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		g.messageEmitter()
+	}()
+	go func() {
+		defer wg.Done()
+		g.run()
+	}()
+
+	go func() {
+		wg.Wait()
+		g.disconnectPlayers()
+		close(g.done)
+	}()
+}
+
+// StopGame ends the session and waits until every player is disconnected
+func (g *Game) StopGame() {
+	g.cancel()
+	<-g.done
+}
+
+// ApplyRules runs apply, which changes the arena settings or the settings in
+// models, between the ticks of every game
+// The given games' arenas are resized to match and their players are sent the new rules
+func ApplyRules(games []*Game, apply func()) {
+	rulesMutex.Lock()
+	apply()
+	rules := models.RulesMessage{
+		ArenaWidth:      ArenaWidth,
+		ArenaHeight:     ArenaHeight,
+		PlayerRadius:    models.PlayerRadius,
+		JunkRadius:      models.JunkRadius,
+		PointsPerJunk:   models.PointsPerJunk,
+		PointsPerPlayer: models.PointsPerPlayer,
+	}
+	for _, g := range games {
+		g.Arena.Resize(ArenaHeight, ArenaWidth)
+	}
+	rulesMutex.Unlock()
+
+	for _, g := range games {
+		g.Emit(models.Message{
+			Type: "rules",
+			Data: &rules,
+		})
+	}
+}
+
+// Emit queues an event for this game's message emitter
+func (g *Game) Emit(msg models.Message) {
+	select {
+	case g.events <- msg:
+	case <-g.ctx.Done():
+	}
 }
 
 // ServeHTTP handles a connection from a client
 // Upgrades client's connection to WebSocket and listens for messages
+// A client connecting with the token query parameter from its initial message
+// resumes control of its player if the player is still in the arena
+// Clients that flood messages are kicked, and addresses in Bans are refused
 func (g *Game) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ip := RemoteIP(r.RemoteAddr)
+	if until, banned := g.Bans.BannedUntil(ip, time.Now()); banned {
+		RateLimitStats.Add("refused", 1)
+		w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+		return
+	}
+
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("%v\n", err)
 		return
 	}
-	defer ws.Close()
+	ws.SetReadLimit(MaxMessageSize)
+	codec := models.CodecFor(ws.Subprotocol())
+	limiter := createRateLimiter()
+
+	// half-open connections stop answering pings, which makes reading fail once PongTimeout passes
+	ws.SetReadDeadline(time.Now().Add(models.PongTimeout))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(models.PongTimeout))
+	})
 
-	player, err := g.Arena.AddPlayer(ws)
+	// the connection closes the WebSocket once every queued message is written
+	conn := models.CreateWebSocketConnection(ws)
+	defer conn.Close()
+
+	player, err := g.connect(conn, r.URL.Query().Get("token"))
 	if err != nil {
-		log.Printf("Error adding player:\n%v", err)
+		refuse(conn, err)
 		return
 	}
 
-	arena.MessageChannel <- models.Message{
-		Type: "connect",
-		Data: player.GetID(),
-	}
-
 	for {
 		var msg models.Message
-		err := ws.ReadJSON(&msg)
+		frameType, data, err := ws.ReadMessage()
 		if err != nil {
 			log.Printf("%v\n", err)
-			g.Arena.RemovePlayer(player)
+			if !g.Sessions.Disconnect(player, conn) {
+				g.removePlayer(player)
+			}
 			break
 		}
+		ws.SetReadDeadline(time.Now().Add(models.PongTimeout))
+
+		err = codec.Decode(frameType, data, &msg)
+
+		switch limiter.check(msg.Type, time.Now()) {
+		case dropMessage:
+			continue
+		case warnClient:
+			RateLimitStats.Add("warned", 1)
+			reject(player, models.ErrorRateLimited, errors.New("too many messages, slow down"))
+			continue
+		case kickClient:
+			g.kick(player, ip)
+			return
+		}
+
+		if err != nil {
+			reject(player, models.ErrorInvalidMessage, err)
+			continue
+		}
 		switch msg.Type {
 		case "spawn":
 			var spawn models.SpawnHandlerMessage
-			err = json.Unmarshal([]byte(msg.Data.(string)), &spawn)
+			err = msg.UnmarshalData(&spawn)
 			if err != nil {
-				log.Printf("%v\n", err)
+				reject(player, models.ErrorInvalidMessage, err)
 				continue
 			}
+			err = spawn.Validate()
+			if err != nil {
+				reject(player, models.ErrorInvalidName, err)
+				continue
+			}
+			rulesMutex.RLock()
 			err := g.Arena.SpawnPlayer(player.GetID(), spawn.Name, spawn.Country)
+			rulesMutex.RUnlock()
 			if err != nil {
-				log.Printf("Error spawning player:\n%v", err)
+				reject(player, models.ErrorSpawnFailed, err)
 				continue
 			}
+			player.SetLastActive(time.Now())
 		case "reconnect":
-			player, err = g.Arena.AddPlayer(ws)
+			// the previous player is normally gone already, as clients reconnect after dying
+			g.removePlayer(player)
+			p, err := g.connect(conn, "")
 			if err != nil {
-				log.Printf("Error adding player:\n%v", err)
-			} else {
-				connectMsg := models.Message{
-					Type: "connect",
-					Data: player.GetID(),
-				}
-				arena.MessageChannel <- connectMsg
+				refuse(conn, err)
+				return
 			}
+			player = p
+		case "ack":
+			var ack models.AckMessage
+			err = msg.UnmarshalData(&ack)
+			if err != nil {
+				reject(player, models.ErrorInvalidMessage, err)
+				continue
+			}
+			g.Snapshots.Ack(player.GetID(), ack.Snapshot)
 		case "keyHandler":
 			var kh models.KeyHandlerMessage
-			err = json.Unmarshal([]byte(msg.Data.(string)), &kh)
+			err = msg.UnmarshalData(&kh)
 			if err != nil {
-				log.Printf("%v\n", err)
+				reject(player, models.ErrorInvalidMessage, err)
 				continue
 			}
 
@@ -107,81 +328,277 @@ func (g *Game) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			} else {
 				player.KeyUpHandler(kh.Key)
 			}
+			player.SetLastInput(kh.Sequence)
+			player.SetLastActive(time.Now())
 		default:
-			log.Println("Unknown message type received")
+			reject(player, models.ErrorUnknownMessage, fmt.Errorf("unknown message type %q", msg.Type))
 		}
 	}
 }
 
+// refuse tells a client no player could be added for it and disconnects it
+// Errors that are error messages are sent with their own code instead of ErrorArenaFull
+func refuse(conn models.Connection, err error) {
+	log.Printf("Error adding player:\n%v", err)
+
+	code := models.ErrorArenaFull
+	if e, ok := err.(*models.ErrorMessage); ok {
+		code = e.Code
+	}
+	err = models.CloseWithError(conn, code, err.Error())
+	if err != nil {
+		log.Printf("error: %v", err)
+	}
+}
+
+// reject tells the player's client that its message could not be handled
+// Errors that are error messages are sent with their own code instead of code
+func reject(p *models.Player, code string, err error) {
+	log.Printf("%v\n", err)
+	if e, ok := err.(*models.ErrorMessage); ok {
+		code = e.Code
+	}
+
+	sendErr := p.Send(models.CreateErrorMessage(code, err.Error()))
+	if sendErr != nil {
+		log.Printf("error: %v", sendErr)
+		p.Close()
+	}
+}
+
+// connect resumes the player of the session with the given token, or adds a
+// new player if there is none, and queues its initial message
+func (g *Game) connect(conn models.Connection, token string) (*models.Player, error) {
+	player := g.Sessions.Resume(token, conn)
+	if player != nil {
+		// the new client has none of the snapshots sent before, so it starts from a keyframe
+		g.Snapshots.Forget(player.GetID())
+		player.SetLastActive(time.Now())
+		log.Printf("Player %s resumed\n", player.GetID())
+	} else {
+		var err error
+		player, err = g.Arena.AddPlayer(conn)
+		if err != nil {
+			return nil, err
+		}
+		// a player without a token that cannot be guessed could be taken over by anyone
+		_, err = g.Sessions.Create(player, conn)
+		if err != nil {
+			g.Arena.RemovePlayer(player)
+			return nil, &models.ErrorMessage{Code: models.ErrorInternal, Message: err.Error()}
+		}
+	}
+
+	g.Emit(models.Message{
+		Type: "connect",
+		Data: player.GetID(),
+	})
+	return player, nil
+}
+
+// run steps the simulation once for every RefreshRate that elapsed and
+// broadcasts the resulting state, so simulation speed does not depend on
+// scheduler jitter
 func (g *Game) run() {
+	ticker := time.NewTicker(g.RefreshRate)
+	defer ticker.Stop()
+
+	previous := time.Now()
+	var lag time.Duration
 	for {
-		time.Sleep(g.RefreshRate)
+		select {
+		case <-g.ctx.Done():
+			return
+		case now := <-ticker.C:
+			lag += now.Sub(previous)
+			previous = now
+		}
 
+		// Drop the backlog if the simulation falls too far behind to catch up
+		steps := 0
+		for ; lag >= g.RefreshRate; lag -= g.RefreshRate {
+			if steps == maxStepsPerFrame {
+				lag = 0
+				break
+			}
+			g.step()
+			steps++
+		}
+
+		if steps > 0 {
+			g.broadcast()
+		}
+
+		for _, p := range g.Sessions.Expire(time.Now()) {
+			g.removePlayer(p)
+		}
+		g.removeAFKPlayers(time.Now())
+	}
+}
+
+// removeAFKPlayers disconnects spawned players who sent no input for AFKTimeout
+func (g *Game) removeAFKPlayers(now time.Time) {
+	if AFKTimeout <= 0 {
+		return
+	}
+
+	for _, p := range g.Arena.GetPlayers() {
+		if p.GetName() == "" || g.Bots.IsBot(p.GetID()) {
+			continue
+		}
+		if now.Sub(p.GetLastActive()) < AFKTimeout {
+			continue
+		}
+
+		log.Printf("Player %s is AFK, disconnecting\n", p.GetID())
+		g.disconnect(p, models.ErrorIdle, fmt.Sprintf("no input for %v", AFKTimeout))
+	}
+}
+
+// step advances the simulation by a single tick and the match with it
+// The arena stands still in the phases of a match players may not move in
+// Rules are only changed between steps
+func (g *Game) step() {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+
+	g.Tick++
+	if g.Match.playing() {
 		g.Arena.UpdatePositions()
 		g.Arena.CollisionDetection()
+		g.Bots.Update(g.Tick)
 	}
+	g.updateMatch()
 }
 
-func (g *Game) tick() {
-	for {
-		time.Sleep(g.RefreshRate)
+// broadcast sends the current state, stamped with the current tick, to every client
+// Rules are locked while snapshots are built, since they read object radii
+func (g *Game) broadcast() {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+
+	state := g.Arena.GetState()
 
-		msg := models.Message{
-			Type: "update",
-			Data: g.Arena.GetState(),
+	// update every client with a keyframe or a delta from its last acknowledged snapshot
+	for _, p := range state.Players {
+		if g.Bots.IsBot(p.GetID()) {
+			continue
 		}
+		msg := g.Snapshots.Message(p, g.Tick, state)
+		err := p.Send(&msg)
+		if err != nil {
+			// the client's read loop disconnects the player once the connection is closed
+			log.Printf("error: %v", err)
+			p.Close()
+		}
+	}
+}
 
-		// update every client
-		for _, p := range g.Arena.GetPlayers() {
-			err := p.SendJSON(&msg)
-			if err != nil {
-				log.Printf("error: %v", err)
-				p.Close()
-				g.Arena.RemovePlayer(p)
-			}
+// disconnectPlayers tells every player the server is shutting down and closes their connections
+func (g *Game) disconnectPlayers() {
+	shutdownMsg := models.Message{
+		Type: "shutdown",
+		Data: nil,
+	}
+
+	for _, p := range g.Arena.GetPlayers() {
+		err := p.Send(&shutdownMsg)
+		if err != nil {
+			log.Printf("error: %v", err)
 		}
+		p.CloseWith(websocket.CloseGoingAway, models.ErrorServerShutdown)
+		g.removePlayer(p)
 	}
 }
 
+// kick disconnects a player whose client flooded the server and records the
+// kick against the client's address
+func (g *Game) kick(p *models.Player, ip string) {
+	log.Printf("Kicking player %s from %s for flooding\n", p.GetID(), ip)
+	RateLimitStats.Add("kicked", 1)
+	g.disconnect(p, models.ErrorRateLimited, "too many messages")
+	g.Bans.Kicked(ip, time.Now())
+}
+
+// disconnect tells the player why it is being disconnected, closes its
+// connection and removes it from the arena
+func (g *Game) disconnect(p *models.Player, code string, message string) {
+	p.CloseWithError(code, message)
+	g.removePlayer(p)
+}
+
+// removePlayer removes the player from the arena along with its snapshot history and session
+func (g *Game) removePlayer(p *models.Player) {
+	g.Arena.RemovePlayer(p)
+	g.Snapshots.Forget(p.GetID())
+	g.Sessions.Forget(p.GetID())
+}
+
 func (g *Game) messageEmitter() {
 	for {
-		msg := <-arena.MessageChannel
+		var msg models.Message
+		select {
+		case <-g.ctx.Done():
+			return
+		case msg = <-g.events:
+		}
 
 		switch msg.Type {
 		case "connect":
 			id := msg.Data.(string)
 			p := g.Arena.GetPlayer(id)
+			if p == nil {
+				continue
+			}
+			height, width := g.Arena.GetSize()
 
 			initalMsg := models.Message{
 				Type: "initial",
 				Data: models.ConnectionMessage{
-					ArenaWidth:  g.Arena.Width,
-					ArenaHeight: g.Arena.Height,
+					ArenaWidth:  width,
+					ArenaHeight: height,
 					PlayerID:    id,
+					TickRate:    models.TickRate,
+					Token:       g.Sessions.Token(id),
+					Team:        p.GetTeam(),
+					Teams:       g.Arena.GetTeams(),
 				},
 			}
 
-			err := p.SendJSON(&initalMsg)
+			err := p.Send(&initalMsg)
+			if err == nil {
+				if status := g.Match.Status(); status != nil {
+					err = p.Send(&models.Message{Type: "phase", Data: status})
+				}
+			}
 			if err != nil {
 				log.Printf("error: %v", err)
 				p.Close()
-				g.Arena.RemovePlayer(p)
 			}
 
 		case "death":
-			id := msg.Data.(string)
+			// the arena has already removed the player
+			p := msg.Data.(*models.Player)
 			deathMsg := models.Message{
 				Type: "death",
 				Data: nil,
 			}
 
-			p := g.Arena.GetPlayer(id)
-			err := p.SendJSON(&deathMsg)
+			err := p.Send(&deathMsg)
 			if err != nil {
 				log.Printf("error: %v", err)
 				p.Close()
 			}
-			g.Arena.RemovePlayer(p)
+			g.removePlayer(p)
+
+		case "rules", "phase", "results":
+			for _, p := range g.Arena.GetPlayers() {
+				err := p.Send(&msg)
+				if err != nil {
+					log.Printf("error: %v", err)
+					p.Close()
+				}
+			}
 
 		default:
 			log.Println("Unknown message type to emit")
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+func TestContextStopsGame(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	g, _ := CreateGame("")
+	conn := models.CreateMemoryConnection()
+	g.Arena.AddPlayer(conn)
+	g.StartGame(ctx)
+	cancel()
+
+	select {
+	case <-g.done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected game to stop when its context is cancelled")
+	}
+
+	messages := conn.Messages()
+	if len(messages) == 0 || messages[len(messages)-1].Type != "shutdown" || !conn.IsClosed() {
+		t.Errorf("Expected players to be sent a shutdown message and disconnected, got %v", messages)
+	}
+
+	// events emitted after the game stopped must not block
+	for i := 0; i <= eventBufferSize; i++ {
+		g.Emit(models.Message{Type: "death", Data: &models.Player{}})
+	}
+	g.StopGame()
+}
+
+func TestEmitBeforeStart(t *testing.T) {
+	g, _ := CreateGame("")
+	g.Emit(models.Message{Type: "rules"})
+	if len(g.events) != 1 {
+		t.Errorf("Expected the event to be queued until the game starts, got %d events", len(g.events))
+	}
+
+	g.StartGame(context.Background())
+	g.StopGame()
+}
+
+func TestApplyRules(t *testing.T) {
+	width, junkRadius := ArenaWidth, models.JunkRadius
+	defer func() { ArenaWidth, models.JunkRadius = width, junkRadius }()
+
+	g, _ := CreateGame("")
+	g.StartGame(context.Background())
+	defer g.StopGame()
+
+	ApplyRules([]*Game{g}, func() {
+		ArenaWidth = width / 2
+		models.JunkRadius = junkRadius * 2
+	})
+
+	_, gotWidth := g.Arena.GetSize()
+	if gotWidth != width/2 {
+		t.Errorf("Expected running arena to be resized to width %g, got %g", width/2, gotWidth)
+	}
+	if r := g.Arena.GetJunk()[0].GetRadius(); r != junkRadius*2 {
+		t.Errorf("Expected running arena to use the new junk radius %g, got %g", junkRadius*2, r)
+	}
+}
+
+func TestAFKPlayersAreRemoved(t *testing.T) {
+	g, _ := CreateGame("")
+	active := models.CreateMemoryConnection()
+	afk := models.CreateMemoryConnection()
+	unspawned := models.CreateMemoryConnection()
+
+	now := time.Now()
+	for _, conn := range []*models.MemoryConnection{active, afk, unspawned} {
+		p, err := g.Arena.AddPlayer(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.SetLastActive(now.Add(-2 * AFKTimeout))
+		if conn == unspawned {
+			continue
+		}
+		g.Arena.SpawnPlayer(p.GetID(), "testy", "CA")
+		if conn == active {
+			p.SetLastActive(now)
+		}
+	}
+
+	g.removeAFKPlayers(now)
+
+	if len(g.Arena.GetPlayers()) != 2 {
+		t.Errorf("Expected only the AFK player to be removed, %d players left", len(g.Arena.GetPlayers()))
+	}
+	messages := afk.Messages()
+	if len(messages) != 1 || messages[0].Type != "error" {
+		t.Errorf("Expected the AFK player to be told why it was disconnected, got %v", messages)
+	}
+	if code, reason := afk.CloseReason(); code != models.CloseIdle || reason != models.ErrorIdle {
+		t.Errorf("Expected the AFK player to be closed as idle, got %d %q", code, reason)
+	}
+	if active.IsClosed() || unspawned.IsClosed() {
+		t.Error("Expected active and unspawned players to stay connected")
+	}
+}
+
+func TestOriginAllowed(t *testing.T) {
+	origins := AllowedOrigins
+	defer func() { AllowedOrigins = origins }()
+
+	testCases := []struct {
+		description string
+		allowed     []string
+		origin      string
+		want        bool
+	}{
+		{"Any origin", []string{"*"}, "https://elsewhere.example", true},
+		{"Listed origin", []string{"https://bumper.example"}, "https://bumper.example", true},
+		{"Unlisted origin", []string{"https://bumper.example"}, "https://elsewhere.example", false},
+		{"Same host", nil, "http://localhost:9090", true},
+		{"No origin", nil, "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			AllowedOrigins = tc.allowed
+			r := httptest.NewRequest("GET", "http://localhost:9090/connect", nil)
+			if tc.origin != "" {
+				r.Header.Set("Origin", tc.origin)
+			}
+
+			if got := OriginAllowed(r); got != tc.want {
+				t.Errorf("Expected OriginAllowed to be %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
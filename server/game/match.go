@@ -0,0 +1,253 @@
+package game
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// Phase is a stage of a match
+type Phase string
+
+// Phases of a match, in the order they are played
+// Players warm up until MatchMinPlayers have spawned and WarmupDuration has
+// passed, then the arena is frozen for a countdown before the match is played
+// Once it ends the arena is frozen again while the results are shown, and
+// the arena is reset before the next warmup
+const (
+	PhaseWarmup     Phase = "warmup"
+	PhaseCountdown  Phase = "countdown"
+	PhaseInProgress Phase = "inProgress"
+	PhaseResults    Phase = "results"
+	PhaseReset      Phase = "reset"
+)
+
+// Match settings for every game, overridden by the game configuration
+// Matches are opted into by setting MatchDuration, by default it is 0 and
+// games run forever without matches
+var (
+	MatchDuration     = time.Duration(0)
+	WarmupDuration    = 30 * time.Second
+	CountdownDuration = 5 * time.Second
+	ResultsDuration   = 10 * time.Second
+	MatchMinPlayers   = 2
+)
+
+// Match tracks the phase of a game's current match
+// Phases are timed in ticks, so a match lasts as long as its simulation
+// A match is disabled while MatchDuration is 0, and players may always move
+type Match struct {
+	mutex   sync.Mutex
+	phase   Phase
+	tick    uint64
+	ends    uint64
+	timed   bool
+	enabled bool
+}
+
+// CreateMatch constructs a match waiting for players to warm up
+func CreateMatch() *Match {
+	return &Match{phase: PhaseWarmup}
+}
+
+// Phase returns the phase the match is in
+func (m *Match) Phase() Phase {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.phase
+}
+
+// Status returns a message telling clients the phase of the match and the
+// time left in it, or nil if matches are disabled
+func (m *Match) Status() *models.PhaseMessage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !m.enabled {
+		return nil
+	}
+	status := &models.PhaseMessage{Phase: string(m.phase)}
+	if m.timed && m.ends > m.tick {
+		status.Remaining = float64(m.ends-m.tick) / models.TickRate
+	}
+	return status
+}
+
+// playing returns whether players may move in the current phase
+func (m *Match) playing() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return !m.enabled || m.phase == PhaseWarmup || m.phase == PhaseInProgress
+}
+
+// enter starts the given phase at tick, ending it once d has passed
+func (m *Match) enter(phase Phase, tick uint64, d time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.phase = phase
+	m.tick = tick
+	m.ends = tick + uint64(math.Ceil(d.Seconds()*models.TickRate))
+	m.timed = true
+}
+
+// hold starts the given phase at tick, lasting until it is left explicitly
+func (m *Match) hold(phase Phase, tick uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.phase = phase
+	m.tick = tick
+	m.timed = false
+}
+
+// advance records that the match reached tick and whether matches are
+// enabled, returning whether its phase is timed and whether the phase is over
+func (m *Match) advance(tick uint64, enabled bool) (timed bool, over bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.tick = tick
+	m.enabled = enabled
+	return m.timed, m.timed && tick >= m.ends
+}
+
+// secondPassed returns whether a whole number of seconds is left in a timed phase
+func (m *Match) secondPassed() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	perSecond := uint64(math.Max(1, math.Round(models.TickRate)))
+	return m.timed && m.ends > m.tick && (m.ends-m.tick)%perSecond == 0
+}
+
+// updateMatch moves the match on to its next phase once the current one is
+// over, telling clients whenever the phase changes and every second of a timed phase
+// It is called at the end of every step, with the rules locked
+func (g *Game) updateMatch() {
+	m := g.Match
+	enabled := MatchDuration > 0
+	timed, over := m.advance(g.Tick, enabled)
+	if !enabled {
+		return
+	}
+
+	switch m.Phase() {
+	case PhaseWarmup:
+		ready := g.spawnedPlayers() >= MatchMinPlayers
+		switch {
+		case !ready && timed:
+			m.hold(PhaseWarmup, g.Tick)
+		case ready && !timed:
+			m.enter(PhaseWarmup, g.Tick, WarmupDuration)
+		case ready && over:
+			m.enter(PhaseCountdown, g.Tick, CountdownDuration)
+		default:
+			g.emitRemaining()
+			return
+		}
+	case PhaseCountdown:
+		if !over {
+			g.emitRemaining()
+			return
+		}
+		g.Arena.ResetScores()
+		m.enter(PhaseInProgress, g.Tick, MatchDuration)
+	case PhaseInProgress:
+		winner, won := g.Arena.Winner()
+		if !won && !over {
+			g.emitRemaining()
+			return
+		}
+		m.enter(PhaseResults, g.Tick, ResultsDuration)
+		g.Emit(models.Message{
+			Type: "results",
+			Data: g.results(winner),
+		})
+	case PhaseResults:
+		if !over {
+			g.emitRemaining()
+			return
+		}
+		m.hold(PhaseReset, g.Tick)
+		g.emitPhase()
+		g.Arena.Reset(HoleCount, JunkCount)
+		m.hold(PhaseWarmup, g.Tick)
+	}
+	g.emitPhase()
+}
+
+// emitRemaining tells clients how long is left in a timed phase every second
+func (g *Game) emitRemaining() {
+	if g.Match.secondPassed() {
+		g.emitPhase()
+	}
+}
+
+// emitPhase tells clients the phase of the match and the time left in it
+func (g *Game) emitPhase() {
+	g.Emit(models.Message{
+		Type: "phase",
+		Data: g.Match.Status(),
+	})
+}
+
+// spawnedPlayers counts the players in the arena that have spawned, including bots
+func (g *Game) spawnedPlayers() int {
+	spawned := 0
+	for _, p := range g.Arena.GetPlayers() {
+		if p.GetName() != "" {
+			spawned++
+		}
+	}
+	return spawned
+}
+
+// results ranks the spawned players and teams by their points
+// The winner is the one declared by the game mode, or otherwise the team or
+// player with the most points
+func (g *Game) results(winner string) *models.ResultsMessage {
+	results := &models.ResultsMessage{
+		Winner:    winner,
+		Standings: make([]models.Standing, 0),
+		Teams:     g.Arena.GetTeams(),
+	}
+	for _, p := range g.Arena.GetPlayers() {
+		if p.GetName() == "" {
+			continue
+		}
+		results.Standings = append(results.Standings, models.Standing{
+			ID:      p.GetID(),
+			Name:    p.GetName(),
+			Country: p.Country,
+			Team:    p.GetTeam(),
+			Points:  p.Points,
+		})
+	}
+
+	sort.SliceStable(results.Standings, func(i, j int) bool {
+		a, b := results.Standings[i], results.Standings[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		return a.Name < b.Name
+	})
+	sort.SliceStable(results.Teams, func(i, j int) bool {
+		return results.Teams[i].Points > results.Teams[j].Points
+	})
+
+	if results.Winner == "" {
+		switch {
+		case len(results.Teams) > 0:
+			results.Winner = results.Teams[0].Name
+		case len(results.Standings) > 0:
+			results.Winner = results.Standings[0].Name
+		}
+	}
+	return results
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ubclaunchpad/bumper/server/arena"
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// winnerMode declares the first player named "winner" the winner
+type winnerMode struct {
+	arena.FreeForAll
+}
+
+func (winnerMode) Winner(players map[string]*models.Player) (string, bool) {
+	for _, p := range players {
+		if p.Name == "winner" {
+			return p.Name, true
+		}
+	}
+	return "", false
+}
+
+// createMatchGame creates a game with the given spawned players, whose events
+// can be read without starting it
+func createMatchGame(t *testing.T, names ...string) (*Game, []*models.Player) {
+	g, _ := CreateGame("")
+	var players []*models.Player
+	for _, name := range names {
+		p, err := g.Arena.AddPlayer(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.Arena.SpawnPlayer(p.GetID(), name, "CA")
+		players = append(players, p)
+	}
+	return g, players
+}
+
+// stepMatch steps the game n times with matches lasting two ticks and every
+// other phase lasting one, returning the phase and results messages emitted
+func stepMatch(g *Game, n int) []models.Message {
+	duration, warmup, countdown, results := MatchDuration, WarmupDuration, CountdownDuration, ResultsDuration
+	MatchDuration = 2 * time.Second / time.Duration(models.TickRate)
+	WarmupDuration, CountdownDuration, ResultsDuration = 0, 0, 0
+	defer func() {
+		MatchDuration, WarmupDuration, CountdownDuration, ResultsDuration = duration, warmup, countdown, results
+	}()
+
+	var messages []models.Message
+	for i := 0; i < n; i++ {
+		g.step()
+		for len(g.events) > 0 {
+			msg := <-g.events
+			if msg.Type == "phase" || msg.Type == "results" {
+				messages = append(messages, msg)
+			}
+		}
+	}
+	return messages
+}
+
+func TestMatchPhases(t *testing.T) {
+	g, players := createMatchGame(t, "first", "second")
+	holes := g.Arena.GetHoles()
+	players[0].Points = 100
+
+	messages := stepMatch(g, 6)
+
+	want := []string{"warmup", "countdown", "inProgress", "results", "results", "reset", "warmup"}
+	if len(messages) != len(want) {
+		t.Fatalf("Expected %d messages, got %v", len(want), messages)
+	}
+	for i, msg := range messages {
+		got := msg.Type
+		if phase, ok := msg.Data.(*models.PhaseMessage); ok {
+			got = phase.Phase
+		}
+		if got != want[i] {
+			t.Errorf("Expected message %d to be %s, got %s", i, want[i], got)
+		}
+	}
+
+	results := messages[3].Data.(*models.ResultsMessage)
+	if len(results.Standings) != 2 {
+		t.Errorf("Expected both players in the standings, got %v", results.Standings)
+	}
+	if results.Standings[0].Points != 0 {
+		t.Errorf("Expected points scored during warmup to be cleared, got %d", results.Standings[0].Points)
+	}
+	if g.Arena.GetHoles()[0] == holes[0] {
+		t.Error("Expected the arena to get fresh holes after the match")
+	}
+}
+
+func TestMatchWaitsForPlayers(t *testing.T) {
+	g, _ := createMatchGame(t, "lonely")
+
+	messages := stepMatch(g, 10)
+
+	if len(messages) != 0 || g.Match.Phase() != PhaseWarmup {
+		t.Errorf("Expected the match to wait in warmup for another player, got %v", messages)
+	}
+}
+
+func TestMatchEndsWithWinner(t *testing.T) {
+	g, players := createMatchGame(t, "first", "second")
+	g.Arena.Mode = winnerMode{}
+
+	stepMatch(g, 3)
+	if g.Match.Phase() != PhaseInProgress {
+		t.Fatalf("Expected the match to be in progress, got %s", g.Match.Phase())
+	}
+
+	players[1].Name = "winner"
+	messages := stepMatch(g, 1)
+	if len(messages) == 0 || messages[0].Type != "results" {
+		t.Fatalf("Expected the match to end once the mode declared a winner, got %v", messages)
+	}
+	if winner := messages[0].Data.(*models.ResultsMessage).Winner; winner != "winner" {
+		t.Errorf("Expected the mode's winner to win, got %q", winner)
+	}
+}
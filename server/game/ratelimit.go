@@ -0,0 +1,189 @@
+package game
+
+import (
+	"expvar"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// Rate limiting related constants
+// Clients whose messages keep being dropped are warned after warnAfter drops and
+// kicked after kickAfter, and drops are forgotten once none happen for strikeWindow
+// Addresses kicked banAfter times within banWindow are refused for banDuration
+const (
+	MaxMessageSize = 1024
+	warnAfter      = 10
+	kickAfter      = 50
+	strikeWindow   = 10 * time.Second
+	banAfter       = 3
+	banWindow      = 10 * time.Minute
+	banDuration    = 15 * time.Minute
+)
+
+// RateLimitStats counts the messages and clients stopped by rate limits,
+// published with the other expvars at /debug/vars on the admin address
+var RateLimitStats = expvar.NewMap("rateLimits")
+
+// rateLimit allows Rate messages per second on average, and bursts of up to Burst
+type rateLimit struct {
+	rate  float64
+	burst float64
+}
+
+// rateLimits returns the limit for each message type a client sends
+// Clients acknowledge every snapshot, so acks scale with the tick rate
+func rateLimits() map[string]rateLimit {
+	return map[string]rateLimit{
+		"keyHandler": {rate: 30, burst: 60},
+		"ack":        {rate: 2 * models.TickRate, burst: 2 * models.TickRate},
+		"spawn":      {rate: 1, burst: 3},
+		"reconnect":  {rate: 1, burst: 3},
+	}
+}
+
+// defaultRateLimit limits message types without a limit of their own, such as invalid messages
+var defaultRateLimit = rateLimit{rate: 5, burst: 10}
+
+// verdict is what to do with a message from a client
+type verdict int
+
+const (
+	allowMessage verdict = iota
+	dropMessage
+	warnClient
+	kickClient
+)
+
+// tokenBucket holds up to burst tokens and gains rate tokens per second,
+// every message takes a token
+type tokenBucket struct {
+	rateLimit
+	tokens float64
+	last   time.Time
+}
+
+// take removes a token, returning false if there was none left
+func (b *tokenBucket) take(now time.Time) bool {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimiter applies a token bucket per message type to a single client
+// It is only used by the goroutine reading the client's messages
+type rateLimiter struct {
+	limits   map[string]rateLimit
+	buckets  map[string]*tokenBucket
+	dropped  int
+	lastDrop time.Time
+}
+
+func createRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		limits:  rateLimits(),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// check takes a token for a message of the given type and decides what to do with it
+func (l *rateLimiter) check(msgType string, now time.Time) verdict {
+	limit, ok := l.limits[msgType]
+	if !ok {
+		msgType = ""
+		limit = defaultRateLimit
+	}
+
+	b, ok := l.buckets[msgType]
+	if !ok {
+		b = &tokenBucket{rateLimit: limit, tokens: limit.burst, last: now}
+		l.buckets[msgType] = b
+	}
+	if b.take(now) {
+		return allowMessage
+	}
+
+	if now.Sub(l.lastDrop) > strikeWindow {
+		l.dropped = 0
+	}
+	l.dropped++
+	l.lastDrop = now
+	RateLimitStats.Add("dropped", 1)
+
+	switch {
+	case l.dropped >= kickAfter:
+		return kickClient
+	case l.dropped == warnAfter:
+		return warnClient
+	default:
+		return dropMessage
+	}
+}
+
+// Bans temporarily refuses connections from addresses whose clients were
+// kicked for flooding too often
+type Bans struct {
+	mutex  sync.Mutex
+	kicks  map[string][]time.Time
+	banned map[string]time.Time
+}
+
+// CreateBans constructs an empty ban list
+func CreateBans() *Bans {
+	return &Bans{
+		kicks:  make(map[string][]time.Time),
+		banned: make(map[string]time.Time),
+	}
+}
+
+// Kicked records that a client from the address was kicked, banning the
+// address if it was kicked too often
+func (b *Bans) Kicked(addr string, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	recent := b.kicks[addr][:0]
+	for _, t := range b.kicks[addr] {
+		if now.Sub(t) < banWindow {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+
+	if len(recent) >= banAfter {
+		delete(b.kicks, addr)
+		b.banned[addr] = now.Add(banDuration)
+		RateLimitStats.Add("banned", 1)
+		return
+	}
+	b.kicks[addr] = recent
+}
+
+// BannedUntil returns when the address's ban ends, and false if it is not banned
+func (b *Bans) BannedUntil(addr string, now time.Time) (time.Time, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	until, ok := b.banned[addr]
+	if ok && !now.Before(until) {
+		delete(b.banned, addr)
+		return time.Time{}, false
+	}
+	return until, ok
+}
+
+// RemoteIP returns the IP address part of a request's remote address
+func RemoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,78 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	testCases := []struct {
+		description string
+		msgType     string
+		interval    time.Duration
+		count       int
+		want        verdict
+	}{
+		{"Steady key presses", "keyHandler", 50 * time.Millisecond, 200, allowMessage},
+		{"Key press burst", "keyHandler", 0, 60, allowMessage},
+		{"Spawn flood", "spawn", 0, 4, dropMessage},
+		{"Warned", "spawn", 0, 3 + warnAfter, warnClient},
+		{"Kicked", "keyHandler", 0, 60 + kickAfter, kickClient},
+		{"Unknown message flood", "bogus", 0, 11, dropMessage},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			l := createRateLimiter()
+			now := time.Now()
+
+			var got verdict
+			for i := 0; i < tc.count; i++ {
+				got = l.check(tc.msgType, now)
+				now = now.Add(tc.interval)
+			}
+			if got != tc.want {
+				t.Errorf("Expected verdict %d for message %d, got %d", tc.want, tc.count, got)
+			}
+		})
+	}
+}
+
+func TestRateLimiterForgetsDrops(t *testing.T) {
+	l := createRateLimiter()
+	now := time.Now()
+
+	for i := 0; i < 3+warnAfter-1; i++ {
+		l.check("spawn", now)
+	}
+	now = now.Add(2 * strikeWindow)
+	for i := 0; i < 3; i++ {
+		l.check("spawn", now)
+	}
+	if got := l.check("spawn", now); got != dropMessage {
+		t.Errorf("Expected drops before the strike window to be forgotten, got verdict %d", got)
+	}
+}
+
+func TestBans(t *testing.T) {
+	b := CreateBans()
+	now := time.Now()
+
+	for i := 0; i < banAfter-1; i++ {
+		b.Kicked("1.2.3.4", now)
+	}
+	if _, banned := b.BannedUntil("1.2.3.4", now); banned {
+		t.Fatal("Expected an address to be banned only after it was kicked repeatedly")
+	}
+
+	b.Kicked("1.2.3.4", now)
+	if _, banned := b.BannedUntil("1.2.3.4", now); !banned {
+		t.Fatal("Expected an address kicked repeatedly to be banned")
+	}
+	if _, banned := b.BannedUntil("5.6.7.8", now); banned {
+		t.Error("Expected other addresses not to be banned")
+	}
+	if _, banned := b.BannedUntil("1.2.3.4", now.Add(banDuration)); banned {
+		t.Error("Expected the ban to expire")
+	}
+}
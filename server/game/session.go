@@ -0,0 +1,193 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// Session related constants
+const (
+	SessionGracePeriod = 30 * time.Second
+	sessionTokenBytes  = 16
+)
+
+// session ties a player to the token its client can resume it with
+// A disconnected session has no connection
+type session struct {
+	token          string
+	player         *models.Player
+	conn           models.Connection
+	disconnectedAt time.Time
+}
+
+// Sessions lets clients resume control of their player after their connection drops
+// Spawned players stay in the arena for GracePeriod after their client disconnects,
+// with their controls released so their ship coasts to a stop
+type Sessions struct {
+	mutex       sync.Mutex
+	sessions    map[string]*session
+	GracePeriod time.Duration
+}
+
+// CreateSessions constructs an empty set of sessions
+func CreateSessions() *Sessions {
+	return &Sessions{
+		sessions:    make(map[string]*session),
+		GracePeriod: SessionGracePeriod,
+	}
+}
+
+// Create starts a session for a player connected through conn and returns its
+// token, or an error if no token could be generated
+func (s *Sessions) Create(p *models.Player, conn models.Connection) (string, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return "", err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sessions[token] = &session{
+		token:  token,
+		player: p,
+		conn:   conn,
+	}
+	return token, nil
+}
+
+// Has returns whether the token belongs to a session, connected or not
+func (s *Sessions) Has(token string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.sessions[token]
+	return ok
+}
+
+// Token returns the token of the given player's session, or "" if it has none
+func (s *Sessions) Token(id string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if sess := s.find(id); sess != nil {
+		return sess.token
+	}
+	return ""
+}
+
+// Resume hands the session's player over to conn, returning nil if the token
+// is unknown or expired
+// A client that is still connected with the same token is disconnected
+func (s *Sessions) Resume(token string, conn models.Connection) *models.Player {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sess, ok := s.sessions[token]
+	if !ok {
+		return nil
+	}
+
+	if sess.conn != nil {
+		sess.conn.Close()
+	}
+	sess.conn = conn
+	sess.disconnectedAt = time.Time{}
+	sess.player.SetConnection(conn)
+	return sess.player
+}
+
+// Disconnect records that conn to the player was lost and returns whether
+// the player should stay in the arena
+// Players that have not spawned yet are not worth resuming, while players that
+// were already resumed through another connection are kept
+func (s *Sessions) Disconnect(p *models.Player, conn models.Connection) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sess := s.find(p.GetID())
+	if sess == nil {
+		return false
+	}
+	if sess.conn != conn {
+		return true
+	}
+	if p.GetName() == "" {
+		delete(s.sessions, sess.token)
+		return false
+	}
+
+	sess.conn = nil
+	sess.disconnectedAt = time.Now()
+	p.SetConnection(models.NoopConnection{})
+	for _, key := range []int{models.LeftKey, models.RightKey, models.UpKey, models.DownKey} {
+		p.KeyUpHandler(key)
+	}
+	log.Printf("Player %s disconnected, keeping it for %v\n", p.GetID(), s.GracePeriod)
+	return true
+}
+
+// Expire ends the sessions that stayed disconnected for longer than
+// GracePeriod and returns their players
+func (s *Sessions) Expire(now time.Time) []*models.Player {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var expired []*models.Player
+	for token, sess := range s.sessions {
+		if sess.conn == nil && now.Sub(sess.disconnectedAt) > s.GracePeriod {
+			expired = append(expired, sess.player)
+			delete(s.sessions, token)
+		}
+	}
+	return expired
+}
+
+// Forget ends the given player's session
+func (s *Sessions) Forget(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if sess := s.find(id); sess != nil {
+		delete(s.sessions, sess.token)
+	}
+}
+
+// Pending returns whether any disconnected player may still be resumed
+func (s *Sessions) Pending() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, sess := range s.sessions {
+		if sess.conn == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sessions) find(id string) *session {
+	for _, sess := range s.sessions {
+		if sess.player.GetID() == id {
+			return sess
+		}
+	}
+	return nil
+}
+
+// newSessionToken returns a random token that cannot be guessed from other players' tokens
+func newSessionToken() (string, error) {
+	b := make([]byte, sessionTokenBytes)
+	_, err := io.ReadFull(rand.Reader, b)
+	if err != nil {
+		return "", fmt.Errorf("generating session token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
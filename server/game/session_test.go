@@ -0,0 +1,111 @@
+package game
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+func TestResumeAfterDisconnect(t *testing.T) {
+	s := CreateSessions()
+	first := models.CreateMemoryConnection()
+	p := models.CreatePlayer("testy", "red", first)
+	p.AddPoints(300)
+	token, _ := s.Create(p, first)
+
+	p.KeyDownHandler(models.UpKey)
+	if !s.Disconnect(p, first) {
+		t.Fatal("Expected a spawned player to stay in the arena after disconnecting")
+	}
+	if p.Controls.Up {
+		t.Error("Expected a disconnected player's controls to be released")
+	}
+	if !s.Pending() {
+		t.Error("Expected a disconnected session to be pending")
+	}
+
+	second := models.CreateMemoryConnection()
+	if s.Resume(token, second) != p {
+		t.Fatal("Expected the token to resume the same player")
+	}
+	if p.GetConnection() != second || p.Points != 300 {
+		t.Errorf("Expected the resumed player to keep its points and use the new connection")
+	}
+	if s.Pending() {
+		t.Error("Expected a resumed session not to be pending")
+	}
+}
+
+func TestResumeTakesOverConnection(t *testing.T) {
+	s := CreateSessions()
+	first := models.CreateMemoryConnection()
+	p := models.CreatePlayer("testy", "red", first)
+	token, _ := s.Create(p, first)
+
+	second := models.CreateMemoryConnection()
+	s.Resume(token, second)
+	if !first.IsClosed() {
+		t.Error("Expected the previous connection to be closed")
+	}
+	if !s.Disconnect(p, first) || p.GetConnection() != second {
+		t.Error("Expected the previous connection closing not to disconnect the resumed player")
+	}
+}
+
+func TestDisconnectUnspawnedPlayer(t *testing.T) {
+	s := CreateSessions()
+	conn := models.CreateMemoryConnection()
+	p := models.CreatePlayer("", "red", conn)
+	token, _ := s.Create(p, conn)
+
+	if s.Disconnect(p, conn) {
+		t.Error("Expected a player that never spawned to be removed")
+	}
+	if s.Resume(token, models.CreateMemoryConnection()) != nil {
+		t.Error("Expected the session of a removed player to end")
+	}
+}
+
+func TestSessionExpires(t *testing.T) {
+	s := CreateSessions()
+	conn := models.CreateMemoryConnection()
+	p := models.CreatePlayer("testy", "red", conn)
+	token, _ := s.Create(p, conn)
+	s.Disconnect(p, conn)
+
+	if expired := s.Expire(time.Now()); len(expired) != 0 {
+		t.Errorf("Expected no session to expire within the grace period, got %d", len(expired))
+	}
+	expired := s.Expire(time.Now().Add(2 * s.GracePeriod))
+	if len(expired) != 1 || expired[0] != p {
+		t.Fatalf("Expected the disconnected player to expire, got %v", expired)
+	}
+	if s.Resume(token, models.CreateMemoryConnection()) != nil {
+		t.Error("Expected an expired session not to resume")
+	}
+}
+
+// failingReader fails every read, as the system's random source might
+type failingReader struct{}
+
+func (failingReader) Read(b []byte) (int, error) {
+	return 0, errors.New("no randomness")
+}
+
+func TestSessionTokenFailure(t *testing.T) {
+	g, _ := CreateGame("")
+	reader := rand.Reader
+	rand.Reader = failingReader{}
+	defer func() { rand.Reader = reader }()
+
+	conn := models.CreateMemoryConnection()
+	if _, err := g.connect(conn, ""); err == nil {
+		t.Fatal("Expected a client to be refused when no session token could be generated")
+	}
+	if len(g.Arena.GetPlayers()) != 0 {
+		t.Error("Expected the player of a refused client to be removed")
+	}
+}
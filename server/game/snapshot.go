@@ -0,0 +1,297 @@
+package game
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+// HistorySize is the number of snapshots kept for each client as delta baselines
+const HistorySize = 64
+
+// Snapshot settings for new games, overridden by the game configuration
+// Objects outside the interest radius are updated FarUpdateRate times a second
+// The keyframe interval and far update rate are converted to a number of
+// snapshots at the tick rate, and 0 turns periodic keyframes or the interest radius off
+var (
+	KeyframeInterval = 2 * time.Second
+	InterestRadius   = 1200.0
+	FarUpdateRate    = 6.0
+)
+
+// Client visible state of each object, compared to detect changes between snapshots
+type holeState struct {
+	Position models.Position
+	Radius   float64
+	IsAlive  bool
+}
+
+type junkState struct {
+	Position models.Position
+	Color    string
+}
+
+type playerState struct {
+	Name     string
+	Country  string
+	Position models.Position
+	Color    string
+	Angle    float64
+	Points   int
+	Team     int
+	Effects  string
+}
+
+type powerUpState struct {
+	Position models.Position
+	Effect   models.Effect
+}
+
+// frame records the state of every object sent to a client in a snapshot
+type frame struct {
+	snapshot uint64
+	objects  map[string]interface{}
+	teams    []models.Team
+}
+
+// clientHistory keeps the frames recently sent to one client,
+// the latest snapshot sent and the latest snapshot the client acknowledged
+type clientHistory struct {
+	last   uint64
+	acked  uint64
+	frames [HistorySize]*frame
+}
+
+// crossed returns whether a multiple of interval was reached since the last snapshot sent
+// Snapshots are numbered by tick and a tick may be skipped when the game falls behind
+func (c *clientHistory) crossed(snapshot uint64, interval uint64) bool {
+	return interval != 0 && snapshot/interval != c.last/interval
+}
+
+// Snapshots builds keyframe and delta updates for every client of a game
+// A keyframe is sent every KeyframeInterval snapshots, and deltas only carry
+// objects within InterestRadius of the client's player at full rate, objects
+// further away are updated every FarUpdateInterval snapshots
+type Snapshots struct {
+	rwMutex           sync.RWMutex
+	current           uint64
+	clients           map[string]*clientHistory
+	KeyframeInterval  uint64
+	InterestRadius    float64
+	FarUpdateInterval uint64
+}
+
+// CreateSnapshots constructs an empty snapshot history using the snapshot settings
+func CreateSnapshots() *Snapshots {
+	return &Snapshots{
+		clients:           make(map[string]*clientHistory),
+		KeyframeInterval:  snapshotsIn(KeyframeInterval),
+		InterestRadius:    InterestRadius,
+		FarUpdateInterval: snapshotsIn(rateInterval(FarUpdateRate)),
+	}
+}
+
+// rateInterval returns the time between updates at the given rate in Hz, or 0 for a rate of 0
+func rateInterval(hz float64) time.Duration {
+	if hz <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / hz)
+}
+
+// snapshotsIn returns the number of snapshots taken in d at the tick rate,
+// at least 1 unless d is 0
+func snapshotsIn(d time.Duration) uint64 {
+	if d <= 0 {
+		return 0
+	}
+	return uint64(math.Max(1, math.Round(d.Seconds()*models.TickRate)))
+}
+
+// Ack records that the given player received the given snapshot
+func (s *Snapshots) Ack(id string, snapshot uint64) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	c, ok := s.clients[id]
+	if !ok || snapshot <= c.acked || snapshot > s.current {
+		return
+	}
+	c.acked = snapshot
+}
+
+// Forget drops the history kept for the given player
+func (s *Snapshots) Forget(id string) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	delete(s.clients, id)
+}
+
+// Message builds the update for a player from the state at the given snapshot
+// A keyframe is sent periodically and whenever the client has no acknowledged
+// baseline left in its history, otherwise only the difference is sent
+// Every update acknowledges the last input processed for the player
+func (s *Snapshots) Message(p *models.Player, snapshot uint64, state *models.UpdateMessage) models.Message {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+
+	if snapshot > s.current {
+		s.current = snapshot
+	}
+
+	c, ok := s.clients[p.GetID()]
+	if !ok {
+		c = &clientHistory{}
+		s.clients[p.GetID()] = c
+	}
+	defer func() { c.last = snapshot }()
+
+	input := models.InputAck{
+		Sequence: p.GetLastInput(),
+		Velocity: p.GetVelocity(),
+	}
+
+	current := captureFrame(snapshot, state)
+	baseline := c.frames[c.acked%HistorySize]
+	c.frames[snapshot%HistorySize] = current
+
+	if c.crossed(snapshot, s.KeyframeInterval) || baseline == nil || baseline.snapshot != c.acked {
+		return models.Message{
+			Type: "update",
+			Data: &models.UpdateMessage{
+				Snapshot: snapshot,
+				Input:    input,
+				Holes:    state.Holes,
+				Junk:     state.Junk,
+				Players:  state.Players,
+				PowerUps: state.PowerUps,
+				Teams:    state.Teams,
+			},
+		}
+	}
+
+	delta := diffFrames(baseline, current, state, s.interest(p, c, snapshot))
+	delta.Input = input
+	return models.Message{
+		Type: "delta",
+		Data: delta,
+	}
+}
+
+// interest returns whether an object should be updated for the given player in this snapshot
+// Players that have not spawned yet have no position and receive every update
+func (s *Snapshots) interest(p *models.Player, c *clientHistory, snapshot uint64) func(models.Object) bool {
+	if p.GetName() == "" || s.FarUpdateInterval == 0 || c.crossed(snapshot, s.FarUpdateInterval) {
+		return func(models.Object) bool { return true }
+	}
+
+	center := p.GetPosition()
+	return func(obj models.Object) bool {
+		position := obj.GetPosition()
+		return math.Hypot(position.X-center.X, position.Y-center.Y) <= s.InterestRadius+obj.GetRadius()
+	}
+}
+
+// captureFrame records the client visible state of every object in state
+func captureFrame(snapshot uint64, state *models.UpdateMessage) *frame {
+	f := &frame{
+		snapshot: snapshot,
+		objects:  make(map[string]interface{}, len(state.Holes)+len(state.Junk)+len(state.Players)+len(state.PowerUps)),
+		teams:    state.Teams,
+	}
+	for _, h := range state.Holes {
+		f.objects[h.GetID()] = holeState{h.Position, h.Radius, h.IsAlive}
+	}
+	for _, j := range state.Junk {
+		f.objects[j.GetID()] = junkState{j.Position, j.Color}
+	}
+	for _, p := range state.Players {
+		f.objects[p.GetID()] = playerState{p.Name, p.Country, p.Position, p.Color, p.Angle, p.Points, p.Team, p.EffectNames()}
+	}
+	for _, pu := range state.PowerUps {
+		f.objects[pu.GetID()] = powerUpState{pu.Position, pu.Effect}
+	}
+	return f
+}
+
+// diffFrames assembles a DeltaMessage containing the objects in current that
+// are new or changed since baseline, and the objects that no longer exist
+// Objects the client is not interested in are left out and keep their baseline
+// state in current, so they are sent once they are of interest again
+func diffFrames(baseline *frame, current *frame, state *models.UpdateMessage, interested func(models.Object) bool) *models.DeltaMessage {
+	delta := &models.DeltaMessage{
+		Snapshot: current.snapshot,
+		Baseline: baseline.snapshot,
+		Holes:    make([]*models.Hole, 0),
+		Junk:     make([]*models.Junk, 0),
+		Players:  make([]*models.Player, 0),
+		PowerUps: make([]*models.PowerUp, 0),
+		Removed:  make([]string, 0),
+	}
+
+	// changed reports whether obj must be sent, deferring it otherwise
+	changed := func(obj models.Object) bool {
+		id := obj.GetID()
+		previous, known := baseline.objects[id]
+		if previous == current.objects[id] {
+			return false
+		}
+		if interested(obj) {
+			return true
+		}
+
+		if known {
+			current.objects[id] = previous
+		} else {
+			delete(current.objects, id)
+		}
+		return false
+	}
+
+	for _, h := range state.Holes {
+		if changed(h) {
+			delta.Holes = append(delta.Holes, h)
+		}
+	}
+	for _, j := range state.Junk {
+		if changed(j) {
+			delta.Junk = append(delta.Junk, j)
+		}
+	}
+	for _, p := range state.Players {
+		if changed(p) {
+			delta.Players = append(delta.Players, p)
+		}
+	}
+	for _, pu := range state.PowerUps {
+		if changed(pu) {
+			delta.PowerUps = append(delta.PowerUps, pu)
+		}
+	}
+	for id := range baseline.objects {
+		if _, ok := current.objects[id]; !ok {
+			delta.Removed = append(delta.Removed, id)
+		}
+	}
+	if !sameTeams(baseline.teams, current.teams) {
+		delta.Teams = current.teams
+	}
+
+	return delta
+}
+
+// sameTeams returns whether both lists hold the same teams with the same scores
+func sameTeams(a []models.Team, b []models.Team) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
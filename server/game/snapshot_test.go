@@ -0,0 +1,212 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/ubclaunchpad/bumper/server/models"
+)
+
+var testPlayer = models.CreatePlayer("", "", nil)
+
+// testTicks numbers snapshots like consecutive game ticks
+type testTicks uint64
+
+func (t *testTicks) next() uint64 {
+	*t++
+	return uint64(*t)
+}
+
+func createTestState() *models.UpdateMessage {
+	return &models.UpdateMessage{
+		Holes: []*models.Hole{models.CreateHole(models.Position{X: 100, Y: 100})},
+		Junk: []*models.Junk{
+			models.CreateJunk(models.Position{X: 200, Y: 200}),
+			models.CreateJunk(models.Position{X: 300, Y: 300}),
+		},
+		Players: []*models.Player{},
+	}
+}
+
+func TestSnapshotKeyframeWithoutAck(t *testing.T) {
+	s := CreateSnapshots()
+	var ticks testTicks
+	state := createTestState()
+
+	for i := 0; i < 3; i++ {
+		msg := s.Message(testPlayer, ticks.next(), state)
+		if msg.Type != "update" {
+			t.Errorf("Client without an acknowledged snapshot received %s instead of a keyframe", msg.Type)
+		}
+	}
+}
+
+func TestSnapshotDelta(t *testing.T) {
+	s := CreateSnapshots()
+	var ticks testTicks
+	state := createTestState()
+
+	baseline := ticks.next()
+	s.Message(testPlayer, baseline, state)
+	s.Ack(testPlayer.GetID(), baseline)
+
+	// Process an input, move one junk and remove the other
+	testPlayer.SetLastInput(3)
+	state.Junk[0].Position = models.Position{X: 250, Y: 250}
+	removed := state.Junk[1].GetID()
+	state.Junk = state.Junk[:1]
+
+	msg := s.Message(testPlayer, ticks.next(), state)
+	if msg.Type != "delta" {
+		t.Fatalf("Expected a delta after acknowledging snapshot %d. Got %s", baseline, msg.Type)
+	}
+
+	delta := msg.Data.(*models.DeltaMessage)
+	if delta.Input.Sequence != testPlayer.GetLastInput() {
+		t.Errorf("Delta acknowledged input %d. Expected %d", delta.Input.Sequence, testPlayer.GetLastInput())
+	}
+	if delta.Baseline != baseline {
+		t.Errorf("Delta has baseline %d. Expected %d", delta.Baseline, baseline)
+	}
+	if len(delta.Holes) != 0 {
+		t.Errorf("Unchanged hole was included in the delta")
+	}
+	if len(delta.Junk) != 1 || delta.Junk[0] != state.Junk[0] {
+		t.Errorf("Moved junk was not included in the delta. Got %v", delta.Junk)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0] != removed {
+		t.Errorf("Removed junk was not reported. Got %v", delta.Removed)
+	}
+}
+
+func TestSnapshotTeams(t *testing.T) {
+	s := CreateSnapshots()
+	var ticks testTicks
+	state := createTestState()
+	state.Teams = []models.Team{{ID: 1, Name: "Red"}, {ID: 2, Name: "Blue"}}
+
+	baseline := ticks.next()
+	s.Message(testPlayer, baseline, state)
+	s.Ack(testPlayer.GetID(), baseline)
+
+	delta := s.Message(testPlayer, ticks.next(), state).Data.(*models.DeltaMessage)
+	if delta.Teams != nil {
+		t.Errorf("Unchanged teams were included in the delta. Got %v", delta.Teams)
+	}
+
+	state.Teams = []models.Team{{ID: 1, Name: "Red", Points: 100}, {ID: 2, Name: "Blue"}}
+	delta = s.Message(testPlayer, ticks.next(), state).Data.(*models.DeltaMessage)
+	if len(delta.Teams) != 2 || delta.Teams[0].Points != 100 {
+		t.Errorf("Changed team scores were not included in the delta. Got %v", delta.Teams)
+	}
+}
+
+func TestSnapshotPeriodicKeyframe(t *testing.T) {
+	s := CreateSnapshots()
+	var ticks testTicks
+	state := createTestState()
+
+	for i := uint64(1); i <= s.KeyframeInterval; i++ {
+		snapshot := ticks.next()
+		msg := s.Message(testPlayer, snapshot, state)
+		s.Ack(testPlayer.GetID(), snapshot)
+
+		if snapshot%s.KeyframeInterval == 0 && msg.Type != "update" {
+			t.Errorf("Expected a keyframe at snapshot %d. Got %s", snapshot, msg.Type)
+		}
+	}
+}
+
+func TestSnapshotIntervalsFollowTickRate(t *testing.T) {
+	tickRate := models.TickRate
+	defer func() { models.TickRate = tickRate }()
+
+	testCases := []struct {
+		tickRate          float64
+		keyframeInterval  uint64
+		farUpdateInterval uint64
+	}{
+		{30, 60, 5},
+		{60, 120, 10},
+		{120, 240, 20},
+	}
+	for _, tc := range testCases {
+		models.TickRate = tc.tickRate
+		s := CreateSnapshots()
+		if s.KeyframeInterval != tc.keyframeInterval || s.FarUpdateInterval != tc.farUpdateInterval {
+			t.Errorf("Expected keyframes every %d and far updates every %d snapshots at %g Hz, got %d and %d",
+				tc.keyframeInterval, tc.farUpdateInterval, tc.tickRate, s.KeyframeInterval, s.FarUpdateInterval)
+		}
+	}
+}
+
+func TestSnapshotSkippedTicks(t *testing.T) {
+	s := CreateSnapshots()
+	state := createTestState()
+
+	s.Message(testPlayer, s.KeyframeInterval-1, state)
+	s.Ack(testPlayer.GetID(), s.KeyframeInterval-1)
+
+	msg := s.Message(testPlayer, s.KeyframeInterval+1, state)
+	if msg.Type != "update" {
+		t.Errorf("Expected a keyframe after skipping tick %d. Got %s", s.KeyframeInterval, msg.Type)
+	}
+}
+
+func TestSnapshotStaleAck(t *testing.T) {
+	s := CreateSnapshots()
+	var ticks testTicks
+	state := createTestState()
+
+	first := ticks.next()
+	s.Message(testPlayer, first, state)
+	s.Ack(testPlayer.GetID(), first)
+
+	// The acknowledged frame falls out of the history
+	for i := 0; i < HistorySize; i++ {
+		s.Message(testPlayer, ticks.next(), state)
+	}
+
+	msg := s.Message(testPlayer, ticks.next(), state)
+	if msg.Type != "update" {
+		t.Errorf("Expected a keyframe once the acknowledged baseline left the history. Got %s", msg.Type)
+	}
+}
+
+func TestSnapshotInterest(t *testing.T) {
+	s := CreateSnapshots()
+	var ticks testTicks
+	state := createTestState()
+
+	p := models.CreatePlayer("viewer", "", nil)
+	p.Position = models.Position{X: 200, Y: 200}
+	state.Junk[1].Position = models.Position{X: 200 + InterestRadius*2, Y: 200}
+
+	baseline := ticks.next()
+	s.Message(p, baseline, state)
+	s.Ack(p.GetID(), baseline)
+
+	// Move the nearby and the distant junk
+	state.Junk[0].Position.X++
+	state.Junk[1].Position.X++
+
+	for snapshot := ticks.next(); snapshot <= s.FarUpdateInterval; snapshot = ticks.next() {
+		delta := s.Message(p, snapshot, state).Data.(*models.DeltaMessage)
+
+		sentFar := false
+		for _, j := range delta.Junk {
+			sentFar = sentFar || j == state.Junk[1]
+		}
+		if snapshot%s.FarUpdateInterval != 0 && sentFar {
+			t.Errorf("Distant junk was sent at snapshot %d", snapshot)
+		}
+		if snapshot%s.FarUpdateInterval == 0 && !sentFar {
+			t.Errorf("Distant junk was not sent at snapshot %d", snapshot)
+		}
+		if snapshot == baseline+1 && (len(delta.Junk) != 1 || delta.Junk[0] != state.Junk[0]) {
+			t.Errorf("Nearby junk was not sent at full rate. Got %v", delta.Junk)
+		}
+		if len(delta.Removed) != 0 {
+			t.Errorf("Deferred junk was reported as removed. Got %v", delta.Removed)
+		}
+	}
+}
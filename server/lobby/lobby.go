@@ -0,0 +1,370 @@
+package lobby
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/ubclaunchpad/bumper/server/bot"
+	"github.com/ubclaunchpad/bumper/server/game"
+)
+
+// Lobby related constants
+const (
+	DefaultRoomCapacity = 30
+	RoomIdleTimeout     = time.Minute
+)
+
+// Connection limits, overridden by the game configuration before the server starts
+// MaxPlayers counts clients connected to any room, bots are not counted
+// A limit of 0 leaves the number of connections unbounded
+var (
+	MaxPlayers          = 300
+	MaxConnectionsPerIP = 10
+)
+
+// Room is a single game instance managed by the lobby
+// Mode is the name of the game mode the room plays
+type Room struct {
+	ID          string
+	Mode        string
+	Game        *game.Game
+	connections int
+	createdAt   time.Time
+}
+
+// Lobby owns every running game and assigns clients to rooms with free capacity
+// Addresses banned from one room are banned from every room
+type Lobby struct {
+	rwMutex  sync.RWMutex
+	Rooms    map[string]*Room
+	Capacity int
+	Bans     *game.Bans
+	ctx      context.Context
+	cancel   context.CancelFunc
+	players  int
+	perIP    map[string]int
+}
+
+// CreateLobby constructs a lobby whose rooms hold at most capacity connections
+// Every room's game stops when ctx is cancelled
+func CreateLobby(ctx context.Context, capacity int) *Lobby {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Lobby{
+		Rooms:    make(map[string]*Room),
+		Capacity: capacity,
+		Bans:     game.CreateBans(),
+		perIP:    make(map[string]int),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start runs the goroutine that reaps idle rooms
+func (l *Lobby) Start() {
+	go l.reap()
+}
+
+// Stop tears down every room and stops the lobby's goroutines
+// It returns once every player has been told the server is shutting down
+func (l *Lobby) Stop() {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	l.cancel()
+	for _, room := range l.Rooms {
+		l.removeRoom(room)
+	}
+}
+
+// Reload changes the rules of every room between ticks and tells its players about them
+// apply sets the new rules and is called once
+func (l *Lobby) Reload(apply func()) {
+	l.rwMutex.RLock()
+	defer l.rwMutex.RUnlock()
+
+	games := make([]*game.Game, 0, len(l.Rooms))
+	for _, room := range l.Rooms {
+		games = append(games, room.Game)
+	}
+	game.ApplyRules(games, apply)
+}
+
+// ConfigureBots changes the number and difficulty of bots playing in a room
+func (l *Lobby) ConfigureBots(id string, count int, difficulty bot.Difficulty) error {
+	room := l.GetRoom(id)
+	if room == nil {
+		return fmt.Errorf("room %s does not exist", id)
+	}
+
+	room.Game.Bots.Configure(count, difficulty)
+	return nil
+}
+
+// ServeBots handles the /bots endpoint by configuring the bots in a room
+// It takes POST requests with the room, count and difficulty query parameters
+// It is served on the admin address only, since it lets anyone fill rooms with bots
+func (l *Lobby) ServeBots(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	count, err := strconv.Atoi(query.Get("count"))
+	if err != nil || count < 0 {
+		http.Error(w, fmt.Sprintf("invalid bot count %q", query.Get("count")), http.StatusBadRequest)
+		return
+	}
+	difficulty, err := bot.ParseDifficulty(query.Get("difficulty"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = l.ConfigureBots(query.Get("room"), count, difficulty)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ServeStart handles the /start endpoint by reserving a room for the client
+// and responding with the location it should connect to
+// Clients can ask for a room playing a game mode with the mode query parameter
+// Only pages from game.AllowedOrigins may read the response
+func (l *Lobby) ServeStart(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); origin != "" && game.OriginAllowed(r) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	w.Header().Add("Vary", "Origin")
+	w.Header().Set("Content-Type", "application/json")
+
+	mode := r.URL.Query().Get("mode")
+	room, err := l.assign("", mode)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := url.Values{"room": {room.ID}}
+	if mode != "" {
+		query.Set("mode", mode)
+	}
+	response := struct {
+		Location string `json:"location"`
+		Room     string `json:"room"`
+		Mode     string `json:"mode"`
+	}{
+		r.Host + "/connect?" + query.Encode(),
+		room.ID,
+		room.Mode,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// ServeHTTP handles the /connect endpoint
+// A client with the token query parameter from its initial message rejoins
+// the room holding its session, even if the room is full
+// Otherwise the client joins the room given by the room query parameter if it still
+// has capacity, otherwise it is moved to any room with free capacity playing
+// the mode given by the mode query parameter, if any
+// Clients are refused with 503 Service Unavailable when the server holds
+// MaxPlayers, and with 429 Too Many Requests when their address already has
+// MaxConnectionsPerIP connections
+func (l *Lobby) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ip := game.RemoteIP(r.RemoteAddr)
+	status := l.admit(ip)
+	if status != http.StatusOK {
+		log.Printf("Refusing client from %s: %s\n", ip, http.StatusText(status))
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	defer l.release(ip)
+
+	query := r.URL.Query()
+	room := l.rejoin(query.Get("token"))
+	if room == nil {
+		var err error
+		room, err = l.join(query.Get("room"), query.Get("mode"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	defer l.leave(room)
+
+	room.Game.ServeHTTP(w, r)
+}
+
+// admit counts a connection from the address against the connection limits,
+// returning the HTTP status to refuse it with or 200 OK if it may connect
+func (l *Lobby) admit(ip string) int {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	if MaxPlayers > 0 && l.players >= MaxPlayers {
+		return http.StatusServiceUnavailable
+	}
+	if MaxConnectionsPerIP > 0 && l.perIP[ip] >= MaxConnectionsPerIP {
+		return http.StatusTooManyRequests
+	}
+
+	l.players++
+	l.perIP[ip]++
+	return http.StatusOK
+}
+
+// release stops counting a connection from the address against the connection limits
+func (l *Lobby) release(ip string) {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	l.players--
+	l.perIP[ip]--
+	if l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// GetRoom returns the room with the given ID, or nil if it does not exist
+func (l *Lobby) GetRoom(id string) *Room {
+	l.rwMutex.RLock()
+	defer l.rwMutex.RUnlock()
+
+	return l.Rooms[id]
+}
+
+// assign finds a room with free capacity playing the given mode, preferring
+// the requested one, and creates a new room if every such room is full
+// An empty mode matches rooms playing any mode
+func (l *Lobby) assign(requested string, mode string) (*Room, error) {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	return l.findRoom(requested, mode)
+}
+
+// join assigns a room and counts the new connection against its capacity
+func (l *Lobby) join(requested string, mode string) (*Room, error) {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	room, err := l.findRoom(requested, mode)
+	if err != nil {
+		return nil, err
+	}
+	room.connections++
+	return room, nil
+}
+
+// rejoin finds the room holding the session with the given token and counts
+// the connection against it, returning nil if no room holds the session
+func (l *Lobby) rejoin(token string) *Room {
+	if token == "" {
+		return nil
+	}
+
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	for _, room := range l.Rooms {
+		if room.Game.Sessions.Has(token) {
+			room.connections++
+			return room
+		}
+	}
+	return nil
+}
+
+// leave releases a connection and tears the room down once it is empty
+// Rooms with disconnected players that may still resume are left for reap
+func (l *Lobby) leave(room *Room) {
+	l.rwMutex.Lock()
+	defer l.rwMutex.Unlock()
+
+	room.connections--
+	if room.connections <= 0 && !room.Game.Sessions.Pending() {
+		l.removeRoom(room)
+	}
+}
+
+func (l *Lobby) findRoom(requested string, mode string) (*Room, error) {
+	joinable := func(room *Room) bool {
+		return room.connections < l.Capacity && (mode == "" || room.Mode == mode)
+	}
+
+	if room, ok := l.Rooms[requested]; ok && joinable(room) {
+		return room, nil
+	}
+
+	for _, room := range l.Rooms {
+		if joinable(room) {
+			return room, nil
+		}
+	}
+
+	return l.addRoom(mode)
+}
+
+func (l *Lobby) addRoom(mode string) (*Room, error) {
+	g, err := game.CreateGame(mode)
+	if err != nil {
+		return nil, err
+	}
+
+	room := &Room{
+		ID:        xid.New().String(),
+		Mode:      g.Arena.Mode.Name(),
+		Game:      g,
+		createdAt: time.Now(),
+	}
+	l.Rooms[room.ID] = room
+	room.Game.Bots.SetCapacity(l.Capacity)
+	room.Game.Bans = l.Bans
+	room.Game.StartGame(l.ctx)
+
+	log.Printf("Created %s room %s (%d rooms)\n", room.Mode, room.ID, len(l.Rooms))
+	return room, nil
+}
+
+func (l *Lobby) removeRoom(room *Room) {
+	if _, ok := l.Rooms[room.ID]; !ok {
+		return
+	}
+
+	delete(l.Rooms, room.ID)
+	room.Game.StopGame()
+
+	log.Printf("Removed room %s (%d rooms)\n", room.ID, len(l.Rooms))
+}
+
+// reap tears down rooms that were reserved through /start but never joined,
+// and rooms whose disconnected players did not resume in time
+func (l *Lobby) reap() {
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-time.After(RoomIdleTimeout):
+		}
+
+		l.rwMutex.Lock()
+		for _, room := range l.Rooms {
+			if room.connections <= 0 && !room.Game.Sessions.Pending() && time.Since(room.createdAt) > RoomIdleTimeout {
+				l.removeRoom(room)
+			}
+		}
+		l.rwMutex.Unlock()
+	}
+}
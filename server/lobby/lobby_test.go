@@ -0,0 +1,205 @@
+package lobby
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ubclaunchpad/bumper/server/arena"
+	"github.com/ubclaunchpad/bumper/server/bot"
+)
+
+const testCapacity = 2
+
+// mustJoin joins the requested room, or any room, playing any mode
+func mustJoin(t *testing.T, l *Lobby, requested string) *Room {
+	room, err := l.join(requested, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return room
+}
+
+// mustAssign reserves a room playing any mode
+func mustAssign(t *testing.T, l *Lobby) *Room {
+	room, err := l.assign("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return room
+}
+
+func TestJoinCreatesRooms(t *testing.T) {
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+
+	first := mustJoin(t, l, "")
+	second := mustJoin(t, l, first.ID)
+	if first != second {
+		t.Errorf("Second client was not placed in the requested room with free capacity")
+	}
+
+	third := mustJoin(t, l, first.ID)
+	if third == first {
+		t.Errorf("Full room accepted another client. Got %d/%d connections", first.connections, testCapacity)
+	}
+
+	if len(l.Rooms) != 2 {
+		t.Errorf("Expected a new room to be created. Got %d rooms", len(l.Rooms))
+	}
+}
+
+func TestJoinUnknownRoom(t *testing.T) {
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+
+	room := mustJoin(t, l, "nonexistent")
+	if l.GetRoom(room.ID) != room {
+		t.Errorf("Client requesting an unknown room was not assigned a running room")
+	}
+}
+
+func TestLeaveRemovesEmptyRoom(t *testing.T) {
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+
+	room := mustJoin(t, l, "")
+	mustJoin(t, l, room.ID)
+
+	l.leave(room)
+	if l.GetRoom(room.ID) == nil {
+		t.Errorf("Room was removed while a client was still connected")
+	}
+
+	l.leave(room)
+	if l.GetRoom(room.ID) != nil {
+		t.Errorf("Empty room was not removed")
+	}
+}
+
+func TestAssignReusesRoom(t *testing.T) {
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+
+	room := mustAssign(t, l)
+	if mustAssign(t, l) != room {
+		t.Errorf("Assign created a new room while an existing room had capacity")
+	}
+}
+
+func TestConfigureBots(t *testing.T) {
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+
+	room := mustAssign(t, l)
+	if err := l.ConfigureBots(room.ID, 1, bot.Hard); err != nil {
+		t.Errorf("Expected bots to be configured, got %v", err)
+	}
+	if err := l.ConfigureBots("missing", 1, bot.Hard); err == nil {
+		t.Error("Expected an error configuring bots in a room that does not exist")
+	}
+}
+
+func TestServeBots(t *testing.T) {
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+	room := mustAssign(t, l)
+
+	testCases := []struct {
+		description string
+		method      string
+		query       string
+		want        int
+	}{
+		{"Configured", http.MethodPost, "room=" + room.ID + "&count=2&difficulty=hard", http.StatusNoContent},
+		{"Not a POST", http.MethodGet, "room=" + room.ID + "&count=2&difficulty=hard", http.StatusMethodNotAllowed},
+		{"Missing count", http.MethodPost, "room=" + room.ID + "&difficulty=hard", http.StatusBadRequest},
+		{"Negative count", http.MethodPost, "room=" + room.ID + "&count=-1&difficulty=hard", http.StatusBadRequest},
+		{"Unknown difficulty", http.MethodPost, "room=" + room.ID + "&count=2&difficulty=impossible", http.StatusBadRequest},
+		{"Unknown room", http.MethodPost, "room=missing&count=2&difficulty=hard", http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			l.ServeBots(w, httptest.NewRequest(tc.method, "/bots?"+tc.query, nil))
+			if w.Code != tc.want {
+				t.Errorf("Expected status %d, got %d: %s", tc.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestConnectionLimits(t *testing.T) {
+	maxPlayers, maxPerIP := MaxPlayers, MaxConnectionsPerIP
+	defer func() { MaxPlayers, MaxConnectionsPerIP = maxPlayers, maxPerIP }()
+	MaxPlayers, MaxConnectionsPerIP = 3, 2
+
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+
+	testCases := []struct {
+		description string
+		ip          string
+		want        int
+	}{
+		{"First connection", "1.1.1.1", http.StatusOK},
+		{"Second connection from the same address", "1.1.1.1", http.StatusOK},
+		{"Too many connections from one address", "1.1.1.1", http.StatusTooManyRequests},
+		{"Connection from another address", "2.2.2.2", http.StatusOK},
+		{"Server full", "3.3.3.3", http.StatusServiceUnavailable},
+	}
+	for _, tc := range testCases {
+		if got := l.admit(tc.ip); got != tc.want {
+			t.Errorf("%s: expected status %d, got %d", tc.description, tc.want, got)
+		}
+	}
+
+	l.release("1.1.1.1")
+	if got := l.admit("3.3.3.3"); got != http.StatusOK {
+		t.Errorf("Expected a released connection to make room, got status %d", got)
+	}
+}
+
+func TestJoinByMode(t *testing.T) {
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+
+	room, err := l.join("", arena.FreeForAllMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if room.Mode != arena.FreeForAllMode {
+		t.Errorf("Expected a %s room, got %s", arena.FreeForAllMode, room.Mode)
+	}
+
+	if _, err := l.join(room.ID, "unknown"); err == nil {
+		t.Error("Expected an error joining an unknown mode")
+	}
+	if len(l.Rooms) != 1 {
+		t.Errorf("Expected no room to be created for an unknown mode, got %d rooms", len(l.Rooms))
+	}
+}
+
+func TestRejoinSessionRoom(t *testing.T) {
+	l := CreateLobby(context.Background(), testCapacity)
+	defer l.Stop()
+
+	first := mustJoin(t, l, "")
+	mustJoin(t, l, first.ID)
+	second := mustJoin(t, l, "")
+
+	p, _ := second.Game.Arena.AddPlayer(nil)
+	token, err := second.Game.Sessions.Create(p, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if room := l.rejoin(token); room != second {
+		t.Errorf("Expected a client resuming its session to rejoin its room, got %v", room)
+	}
+	if room := l.rejoin("unknown"); room != nil {
+		t.Errorf("Expected an unknown token not to match a room, got %v", room)
+	}
+}
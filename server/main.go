@@ -1,47 +1,132 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"expvar"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
 	"time"
 
-	"github.com/ubclaunchpad/bumper/server/arena"
-	"github.com/ubclaunchpad/bumper/server/game"
-	"github.com/ubclaunchpad/bumper/server/models"
+	"github.com/ubclaunchpad/bumper/server/config"
+	"github.com/ubclaunchpad/bumper/server/database"
+	"github.com/ubclaunchpad/bumper/server/lobby"
 )
 
-func getLobby(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Content-Type", "application/json")
-
-	response := struct {
-		Location string `json:"location"`
-	}{
-		"localhost:9090",
-	}
-
-	json.NewEncoder(w).Encode(response)
-}
+// ShutdownTimeout bounds how long the server waits for connections and
+// leaderboard writes to finish when it is asked to stop
+const ShutdownTimeout = 10 * time.Second
 
 func main() {
 	rand.Seed(time.Now().UTC().UnixNano())
 
-	arena.MessageChannel = make(chan models.Message)
-	game := game.CreateGame()
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.Apply()
+
+	l := lobby.CreateLobby(context.Background(), lobby.DefaultRoomCapacity)
 
 	// database.ConnectDB("service-account.json")
 	// if database.DBC == nil {
 	// 	log.Println("DBClient not initialized correctly")
 	// }
 
-	http.Handle("/", http.FileServer(http.Dir("./build")))
-	http.HandleFunc("/start", getLobby)
-	http.Handle("/connect", game)
-	game.StartGame()
+	// the public server gets its own mux, since imported packages such as
+	// expvar register debugging handlers on the default one
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir("./build")))
+	mux.HandleFunc("/start", l.ServeStart)
+	mux.Handle("/connect", l)
+	l.Start()
+
+	server := &http.Server{Addr: ":" + os.Getenv("PORT"), Handler: mux}
+	go func() {
+		log.Println("Starting server on localhost:" + os.Getenv("PORT"))
+		err := server.ListenAndServe()
+		if err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+
+	// server statistics are only published on the admin address, if one is set
+	admin := &http.Server{Addr: os.Getenv("ADMIN_ADDR")}
+	if admin.Addr != "" {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("/debug/vars", expvar.Handler())
+		adminMux.HandleFunc("/bots", l.ServeBots)
+		admin.Handler = adminMux
+		go func() {
+			log.Println("Starting admin server on " + admin.Addr)
+			err := admin.ListenAndServe()
+			if err != http.ErrServerClosed {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	// SIGHUP reloads the rules from the config without restarting running games
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	for running := true; running; {
+		select {
+		case <-reload:
+			cfg = reloadConfig(l, cfg)
+		case <-stop:
+			running = false
+		}
+	}
+	log.Println("Shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	// stop accepting connections, then disconnect every player from its game
+	err = server.Shutdown(ctx)
+	if err != nil {
+		log.Printf("error shutting down server: %v", err)
+	}
+	err = admin.Shutdown(ctx)
+	if err != nil {
+		log.Printf("error shutting down admin server: %v", err)
+	}
+	l.Stop()
+
+	err = database.Flush(ctx)
+	if err != nil {
+		log.Printf("error flushing leaderboard writes: %v", err)
+	}
+}
+
+// reloadConfig loads the config again, applies its rules to every running game
+// and returns the config in effect
+// Settings that can only be changed by restarting keep their current values,
+// and the current config is kept if the new one is invalid
+func reloadConfig(l *lobby.Lobby, current *config.Config) *config.Config {
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		log.Printf("error reloading config: %v", err)
+		return current
+	}
+	if cfg.TickRate != current.TickRate {
+		log.Println("tickRate can only be changed by restarting the server")
+	}
+	if cfg.Snapshots != current.Snapshots || cfg.Connection != current.Connection || !reflect.DeepEqual(cfg.Server, current.Server) {
+		log.Println("snapshot, connection and server settings can only be changed by restarting the server")
+	}
+	cfg.TickRate = current.TickRate
+	cfg.Snapshots = current.Snapshots
+	cfg.Connection = current.Connection
+	cfg.Server = current.Server
 
-	log.Println("Starting server on localhost:" + os.Getenv("PORT"))
-	log.Println(http.ListenAndServe(":"+os.Getenv("PORT"), nil))
+	l.Reload(cfg.ApplyRules)
+	log.Println("Reloaded config")
+	return cfg
 }
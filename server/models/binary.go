@@ -0,0 +1,479 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/gorilla/websocket"
+)
+
+// Binary message type codes, the first byte of every binary frame
+const (
+	initialCode byte = iota + 1
+	updateCode
+	deltaCode
+	deathCode
+	spawnCode
+	keyHandlerCode
+	ackCode
+	reconnectCode
+	shutdownCode
+	rulesCode
+	errorCode
+	phaseCode
+	resultsCode
+)
+
+var messageCodes = map[string]byte{
+	"initial":    initialCode,
+	"update":     updateCode,
+	"delta":      deltaCode,
+	"death":      deathCode,
+	"spawn":      spawnCode,
+	"keyHandler": keyHandlerCode,
+	"ack":        ackCode,
+	"reconnect":  reconnectCode,
+	"shutdown":   shutdownCode,
+	"rules":      rulesCode,
+	"error":      errorCode,
+	"phase":      phaseCode,
+	"results":    resultsCode,
+}
+
+// BinaryCodec encodes messages as compact binary frames
+// Numbers are varints, coordinates are little endian float32 and strings are
+// prefixed by their length
+type BinaryCodec struct{}
+
+// Encode writes the message's type code followed by its data
+func (BinaryCodec) Encode(m *Message) (int, []byte, error) {
+	code, ok := messageCodes[m.Type]
+	if !ok {
+		return 0, nil, fmt.Errorf("no binary encoding for %s message", m.Type)
+	}
+
+	w := &binaryWriter{}
+	w.buf.WriteByte(code)
+
+	switch data := m.Data.(type) {
+	case nil:
+	case ConnectionMessage:
+		w.writeConnection(&data)
+	case *ConnectionMessage:
+		w.writeConnection(data)
+	case *UpdateMessage:
+		w.writeUint(data.Snapshot)
+		w.writeInput(data.Input)
+		w.writeObjects(data.Holes, data.Junk, data.Players, data.PowerUps)
+		w.writeTeams(data.Teams)
+	case *DeltaMessage:
+		w.writeUint(data.Snapshot)
+		w.writeUint(data.Baseline)
+		w.writeInput(data.Input)
+		w.writeObjects(data.Holes, data.Junk, data.Players, data.PowerUps)
+		w.writeUint(uint64(len(data.Removed)))
+		for _, id := range data.Removed {
+			w.writeString(id)
+		}
+		w.writeTeams(data.Teams)
+	case *SpawnHandlerMessage:
+		w.writeString(data.Name)
+		w.writeString(data.Country)
+	case *KeyHandlerMessage:
+		w.writeInt(int64(data.Key))
+		w.writeBool(data.IsPressed)
+		w.writeUint(data.Sequence)
+	case *AckMessage:
+		w.writeUint(data.Snapshot)
+	case *RulesMessage:
+		w.writeFloat(data.ArenaWidth)
+		w.writeFloat(data.ArenaHeight)
+		w.writeFloat(data.PlayerRadius)
+		w.writeFloat(data.JunkRadius)
+		w.writeInt(int64(data.PointsPerJunk))
+		w.writeInt(int64(data.PointsPerPlayer))
+	case *ErrorMessage:
+		w.writeString(data.Code)
+		w.writeString(data.Message)
+	case *PhaseMessage:
+		w.writeString(data.Phase)
+		w.writeFloat(data.Remaining)
+	case *ResultsMessage:
+		w.writeString(data.Winner)
+		w.writeUint(uint64(len(data.Standings)))
+		for _, s := range data.Standings {
+			w.writeString(s.ID)
+			w.writeString(s.Name)
+			w.writeString(s.Country)
+			w.writeUint(uint64(s.Team))
+			w.writeInt(int64(s.Points))
+		}
+		w.writeTeams(data.Teams)
+	default:
+		return 0, nil, fmt.Errorf("no binary encoding for %s data of type %T", m.Type, m.Data)
+	}
+
+	return websocket.BinaryMessage, w.buf.Bytes(), nil
+}
+
+// Decode reads a binary frame into a message whose data is a pointer to the
+// struct for its type
+func (BinaryCodec) Decode(frameType int, data []byte, m *Message) error {
+	if frameType != websocket.BinaryMessage || len(data) == 0 {
+		return errors.New("expected a non-empty binary frame")
+	}
+
+	r := &binaryReader{buf: bytes.NewReader(data[1:])}
+	m.Data = nil
+
+	switch data[0] {
+	case initialCode:
+		m.Type = "initial"
+		m.Data = &ConnectionMessage{
+			ArenaWidth:  r.readFloat(),
+			ArenaHeight: r.readFloat(),
+			PlayerID:    r.readString(),
+			TickRate:    r.readFloat(),
+			Token:       r.readString(),
+			Team:        int(r.readUint()),
+			Teams:       r.readTeams(),
+		}
+	case updateCode:
+		update := &UpdateMessage{Snapshot: r.readUint(), Input: r.readInput()}
+		update.Holes, update.Junk, update.Players, update.PowerUps = r.readObjects()
+		update.Teams = r.readTeams()
+		m.Type = "update"
+		m.Data = update
+	case deltaCode:
+		delta := &DeltaMessage{Snapshot: r.readUint(), Baseline: r.readUint(), Input: r.readInput()}
+		delta.Holes, delta.Junk, delta.Players, delta.PowerUps = r.readObjects()
+		delta.Removed = make([]string, r.readCount())
+		for i := range delta.Removed {
+			delta.Removed[i] = r.readString()
+		}
+		delta.Teams = r.readTeams()
+		m.Type = "delta"
+		m.Data = delta
+	case deathCode:
+		m.Type = "death"
+	case spawnCode:
+		m.Type = "spawn"
+		m.Data = &SpawnHandlerMessage{
+			Name:    r.readString(),
+			Country: r.readString(),
+		}
+	case keyHandlerCode:
+		m.Type = "keyHandler"
+		m.Data = &KeyHandlerMessage{
+			Key:       int(r.readInt()),
+			IsPressed: r.readBool(),
+			Sequence:  r.readUint(),
+		}
+	case ackCode:
+		m.Type = "ack"
+		m.Data = &AckMessage{Snapshot: r.readUint()}
+	case reconnectCode:
+		m.Type = "reconnect"
+	case shutdownCode:
+		m.Type = "shutdown"
+	case rulesCode:
+		m.Type = "rules"
+		m.Data = &RulesMessage{
+			ArenaWidth:      r.readFloat(),
+			ArenaHeight:     r.readFloat(),
+			PlayerRadius:    r.readFloat(),
+			JunkRadius:      r.readFloat(),
+			PointsPerJunk:   int(r.readInt()),
+			PointsPerPlayer: int(r.readInt()),
+		}
+	case errorCode:
+		m.Type = "error"
+		m.Data = &ErrorMessage{
+			Code:    r.readString(),
+			Message: r.readString(),
+		}
+	case phaseCode:
+		m.Type = "phase"
+		m.Data = &PhaseMessage{
+			Phase:     r.readString(),
+			Remaining: r.readFloat(),
+		}
+	case resultsCode:
+		results := &ResultsMessage{Winner: r.readString()}
+		results.Standings = make([]Standing, r.readCount())
+		for i := range results.Standings {
+			results.Standings[i] = Standing{
+				ID:      r.readString(),
+				Name:    r.readString(),
+				Country: r.readString(),
+				Team:    int(r.readUint()),
+				Points:  int(r.readInt()),
+			}
+		}
+		results.Teams = r.readTeams()
+		m.Type = "results"
+		m.Data = results
+	default:
+		return fmt.Errorf("unknown binary message type %d", data[0])
+	}
+
+	return r.err
+}
+
+type binaryWriter struct {
+	buf     bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (w *binaryWriter) writeUint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:], v)
+	w.buf.Write(w.scratch[:n])
+}
+
+func (w *binaryWriter) writeInt(v int64) {
+	n := binary.PutVarint(w.scratch[:], v)
+	w.buf.Write(w.scratch[:n])
+}
+
+func (w *binaryWriter) writeFloat(v float64) {
+	binary.LittleEndian.PutUint32(w.scratch[:4], math.Float32bits(float32(v)))
+	w.buf.Write(w.scratch[:4])
+}
+
+func (w *binaryWriter) writeBool(v bool) {
+	if v {
+		w.buf.WriteByte(1)
+	} else {
+		w.buf.WriteByte(0)
+	}
+}
+
+func (w *binaryWriter) writeString(v string) {
+	w.writeUint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+func (w *binaryWriter) writePosition(p Position) {
+	w.writeFloat(p.X)
+	w.writeFloat(p.Y)
+}
+
+func (w *binaryWriter) writeInput(i InputAck) {
+	w.writeUint(i.Sequence)
+	w.writeFloat(i.Velocity.Dx)
+	w.writeFloat(i.Velocity.Dy)
+}
+
+func (w *binaryWriter) writeConnection(c *ConnectionMessage) {
+	w.writeFloat(c.ArenaWidth)
+	w.writeFloat(c.ArenaHeight)
+	w.writeString(c.PlayerID)
+	w.writeFloat(c.TickRate)
+	w.writeString(c.Token)
+	w.writeUint(uint64(c.Team))
+	w.writeTeams(c.Teams)
+}
+
+func (w *binaryWriter) writeObjects(holes []*Hole, junk []*Junk, players []*Player, powerUps []*PowerUp) {
+	w.writeUint(uint64(len(holes)))
+	for _, h := range holes {
+		w.writeString(h.ID)
+		w.writePosition(h.Position)
+		w.writeFloat(h.Radius)
+		w.writeBool(h.IsAlive)
+	}
+
+	w.writeUint(uint64(len(junk)))
+	for _, j := range junk {
+		w.writeString(j.ID)
+		w.writePosition(j.Position)
+		w.writeString(j.Color)
+	}
+
+	w.writeUint(uint64(len(players)))
+	for _, p := range players {
+		w.writeString(p.ID)
+		w.writeString(p.Name)
+		w.writeString(p.Country)
+		w.writePosition(p.Position)
+		w.writeString(p.Color)
+		w.writeFloat(p.Angle)
+		w.writeInt(int64(p.Points))
+		w.writeUint(uint64(p.Team))
+		w.writeUint(uint64(len(p.Effects)))
+		for _, e := range p.Effects {
+			w.writeString(string(e.Effect))
+			w.writeFloat(e.Remaining)
+		}
+	}
+
+	w.writeUint(uint64(len(powerUps)))
+	for _, pu := range powerUps {
+		w.writeString(pu.ID)
+		w.writePosition(pu.Position)
+		w.writeString(string(pu.Effect))
+	}
+}
+
+func (w *binaryWriter) writeTeams(teams []Team) {
+	w.writeUint(uint64(len(teams)))
+	for _, t := range teams {
+		w.writeUint(uint64(t.ID))
+		w.writeString(t.Name)
+		w.writeString(t.Color)
+		w.writeInt(int64(t.Points))
+	}
+}
+
+// binaryReader reads values until the first error, after which it returns zero values
+type binaryReader struct {
+	buf *bytes.Reader
+	err error
+}
+
+func (r *binaryReader) readUint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(r.buf)
+	r.err = err
+	return v
+}
+
+func (r *binaryReader) readInt() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(r.buf)
+	r.err = err
+	return v
+}
+
+func (r *binaryReader) readFloat() float64 {
+	if r.err != nil {
+		return 0
+	}
+	var bits uint32
+	r.err = binary.Read(r.buf, binary.LittleEndian, &bits)
+	return float64(math.Float32frombits(bits))
+}
+
+func (r *binaryReader) readBool() bool {
+	if r.err != nil {
+		return false
+	}
+	b, err := r.buf.ReadByte()
+	r.err = err
+	return b != 0
+}
+
+func (r *binaryReader) readString() string {
+	n := r.readCount()
+	if r.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	_, r.err = io.ReadFull(r.buf, b)
+	return string(b)
+}
+
+// readCount reads a length prefix, rejecting lengths longer than the remaining data
+func (r *binaryReader) readCount() int {
+	n := r.readUint()
+	if r.err == nil && n > uint64(r.buf.Len()) {
+		r.err = errors.New("binary message length exceeds frame size")
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (r *binaryReader) readPosition() Position {
+	return Position{X: r.readFloat(), Y: r.readFloat()}
+}
+
+func (r *binaryReader) readInput() InputAck {
+	return InputAck{
+		Sequence: r.readUint(),
+		Velocity: Velocity{Dx: r.readFloat(), Dy: r.readFloat()},
+	}
+}
+
+func (r *binaryReader) readObjects() ([]*Hole, []*Junk, []*Player, []*PowerUp) {
+	holes := make([]*Hole, r.readCount())
+	for i := range holes {
+		holes[i] = &Hole{
+			ID:       r.readString(),
+			Position: r.readPosition(),
+			Radius:   r.readFloat(),
+			IsAlive:  r.readBool(),
+		}
+	}
+
+	junk := make([]*Junk, r.readCount())
+	for i := range junk {
+		junk[i] = &Junk{
+			ID:       r.readString(),
+			Position: r.readPosition(),
+			Color:    r.readString(),
+		}
+	}
+
+	players := make([]*Player, r.readCount())
+	for i := range players {
+		players[i] = &Player{
+			ID:       r.readString(),
+			Name:     r.readString(),
+			Country:  r.readString(),
+			Position: r.readPosition(),
+			Color:    r.readString(),
+			Angle:    r.readFloat(),
+			Points:   int(r.readInt()),
+			Team:     int(r.readUint()),
+		}
+		if n := r.readCount(); n > 0 {
+			players[i].Effects = make([]ActiveEffect, n)
+			for j := range players[i].Effects {
+				players[i].Effects[j] = ActiveEffect{
+					Effect:    Effect(r.readString()),
+					Remaining: r.readFloat(),
+				}
+			}
+		}
+	}
+
+	powerUps := make([]*PowerUp, r.readCount())
+	for i := range powerUps {
+		powerUps[i] = &PowerUp{
+			ID:       r.readString(),
+			Position: r.readPosition(),
+			Effect:   Effect(r.readString()),
+		}
+	}
+
+	return holes, junk, players, powerUps
+}
+
+// readTeams reads a list of teams, returning nil for an empty list
+func (r *binaryReader) readTeams() []Team {
+	n := r.readCount()
+	if n == 0 {
+		return nil
+	}
+
+	teams := make([]Team, n)
+	for i := range teams {
+		teams[i] = Team{
+			ID:     int(r.readUint()),
+			Name:   r.readString(),
+			Color:  r.readString(),
+			Points: int(r.readInt()),
+		}
+	}
+	return teams
+}
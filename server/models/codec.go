@@ -0,0 +1,71 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket subprotocols a client can negotiate to pick a wire encoding
+const (
+	JSONSubprotocol   = "bumper.json"
+	BinarySubprotocol = "bumper.binary"
+)
+
+// Subprotocols lists the supported subprotocols in order of preference
+var Subprotocols = []string{BinarySubprotocol, JSONSubprotocol}
+
+// Codec encodes and decodes messages sent over a WebSocket connection
+type Codec interface {
+	// Encode returns the WebSocket frame type and payload for a message
+	Encode(m *Message) (int, []byte, error)
+	// Decode reads a message from a WebSocket frame
+	Decode(frameType int, data []byte, m *Message) error
+}
+
+// CodecFor returns the codec for a negotiated subprotocol
+// JSON is used when the client did not ask for a subprotocol
+func CodecFor(subprotocol string) Codec {
+	if subprotocol == BinarySubprotocol {
+		return BinaryCodec{}
+	}
+	return JSONCodec{}
+}
+
+// JSONCodec encodes messages as JSON text frames
+// Clients send message data as a JSON string nested in the message
+type JSONCodec struct{}
+
+// Encode marshals the message to JSON
+func (JSONCodec) Encode(m *Message) (int, []byte, error) {
+	data, err := json.Marshal(m)
+	return websocket.TextMessage, data, err
+}
+
+// Decode unmarshals a JSON message
+func (JSONCodec) Decode(frameType int, data []byte, m *Message) error {
+	return json.Unmarshal(data, m)
+}
+
+// UnmarshalData stores the message's data in the value pointed to by v
+// Data is either a JSON string from a JSON client or a value of the same type
+// as v decoded from a binary frame
+func (m *Message) UnmarshalData(v interface{}) error {
+	if data, ok := m.Data.(string); ok {
+		return json.Unmarshal([]byte(data), v)
+	}
+
+	dst := reflect.ValueOf(v)
+	src := reflect.ValueOf(m.Data)
+	if src.Kind() == reflect.Ptr {
+		src = src.Elem()
+	}
+	if dst.Kind() != reflect.Ptr || !src.IsValid() || src.Type() != dst.Elem().Type() {
+		return fmt.Errorf("cannot unmarshal %s data of type %T into %T", m.Type, m.Data, v)
+	}
+
+	dst.Elem().Set(src)
+	return nil
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBinaryCodecRoundTrip(t *testing.T) {
+	players := []*Player{{ID: "p1", Name: "testy", Country: "CA", Position: Position{10, 20}, Color: "#ABCDEF", Angle: 1.5, Points: 600}}
+	holes := []*Hole{{ID: "h1", Position: Position{30, 40}, Radius: 25, IsAlive: true}}
+	junk := []*Junk{{ID: "j1", Position: Position{50, 60}, Color: "white"}}
+	teamPlayers := []*Player{{ID: "p2", Name: "teamy", Color: "#3498DB", Points: -500, Team: 2, Effects: []ActiveEffect{{Effect: ShieldEffect, Remaining: 2.5}}}}
+	powerUps := []*PowerUp{{ID: "u1", Position: Position{70, 80}, Effect: MagnetEffect}}
+	teams := []Team{{ID: 1, Name: "Red", Color: "#E74C3C", Points: 300}, {ID: 2, Name: "Blue", Color: "#3498DB", Points: -500}}
+
+	testCases := []struct {
+		description string
+		msg         Message
+	}{
+		{"initial", Message{"initial", &ConnectionMessage{ArenaWidth: 2800, ArenaHeight: 2400, PlayerID: "p1", TickRate: 60, Token: "t1"}}},
+		{"update", Message{"update", &UpdateMessage{Snapshot: 42, Input: InputAck{Sequence: 7, Velocity: Velocity{1.5, -2}}, Holes: holes, Junk: junk, Players: players, PowerUps: powerUps}}},
+		{"delta", Message{"delta", &DeltaMessage{Snapshot: 43, Baseline: 42, Holes: []*Hole{}, Junk: junk, Players: []*Player{}, PowerUps: []*PowerUp{}, Removed: []string{"j2"}}}},
+		{"initial with teams", Message{"initial", &ConnectionMessage{ArenaWidth: 2800, ArenaHeight: 2400, PlayerID: "p2", TickRate: 60, Token: "t2", Team: 2, Teams: teams}}},
+		{"update with teams", Message{"update", &UpdateMessage{Snapshot: 44, Holes: []*Hole{}, Junk: []*Junk{}, Players: teamPlayers, PowerUps: []*PowerUp{}, Teams: teams}}},
+		{"delta with teams", Message{"delta", &DeltaMessage{Snapshot: 45, Baseline: 44, Holes: []*Hole{}, Junk: []*Junk{}, Players: teamPlayers, PowerUps: powerUps, Removed: []string{}, Teams: teams}}},
+		{"death", Message{"death", nil}},
+		{"shutdown", Message{"shutdown", nil}},
+		{"spawn", Message{"spawn", &SpawnHandlerMessage{Name: "testy", Country: "CA"}}},
+		{"keyHandler", Message{"keyHandler", &KeyHandlerMessage{Key: UpKey, IsPressed: true, Sequence: 7}}},
+		{"ack", Message{"ack", &AckMessage{Snapshot: 42}}},
+		{"rules", Message{"rules", &RulesMessage{ArenaWidth: 2000, ArenaHeight: 1500, PlayerRadius: 30, JunkRadius: 12, PointsPerJunk: 150, PointsPerPlayer: 400}}},
+		{"error", Message{"error", &ErrorMessage{Code: ErrorIdle, Message: "no input for 2m0s"}}},
+		{"phase", Message{"phase", &PhaseMessage{Phase: "countdown", Remaining: 4.5}}},
+		{"results", Message{"results", &ResultsMessage{Winner: "Blue", Standings: []Standing{{ID: "p2", Name: "teamy", Country: "CA", Team: 2, Points: 800}}, Teams: teams}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			codec := BinaryCodec{}
+			frameType, data, err := codec.Encode(&tc.msg)
+			if err != nil {
+				t.Fatalf("Failed to encode %s: %v", tc.description, err)
+			}
+
+			var decoded Message
+			err = codec.Decode(frameType, data, &decoded)
+			if err != nil {
+				t.Fatalf("Failed to decode %s: %v", tc.description, err)
+			}
+			if !reflect.DeepEqual(decoded, tc.msg) {
+				t.Errorf("Decoded %+v. Expected %+v", decoded, tc.msg)
+			}
+		})
+	}
+}
+
+func TestBinaryCodecTruncated(t *testing.T) {
+	codec := BinaryCodec{}
+	frameType, data, _ := codec.Encode(&Message{"spawn", &SpawnHandlerMessage{Name: "testy", Country: "CA"}})
+
+	var decoded Message
+	if err := codec.Decode(frameType, data[:len(data)-1], &decoded); err == nil {
+		t.Error("Decoding a truncated frame did not return an error")
+	}
+}
+
+func TestUnmarshalData(t *testing.T) {
+	testCases := []struct {
+		description string
+		msg         Message
+	}{
+		{"JSON string", Message{"keyHandler", `{"key":38,"isPressed":true}`}},
+		{"binary", Message{"keyHandler", &KeyHandlerMessage{Key: UpKey, IsPressed: true, Sequence: 7}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var kh KeyHandlerMessage
+			err := tc.msg.UnmarshalData(&kh)
+			if err != nil || kh.Key != UpKey || !kh.IsPressed {
+				t.Errorf("Got %+v, %v. Expected up key pressed", kh, err)
+			}
+		})
+	}
+
+	var spawn SpawnHandlerMessage
+	msg := Message{"keyHandler", &KeyHandlerMessage{Key: UpKey}}
+	if err := msg.UnmarshalData(&spawn); err == nil {
+		t.Error("Unmarshalling data of a different type did not return an error")
+	}
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"math"
+)
+
+// body is an object that is pushed around when it collides with another
+type body interface {
+	Object
+	setVelocity(v Velocity)
+}
+
+// collide bounces two touching bodies off each other along the line between
+// their centres, conserving their momentum
+// Restitution is how much of their closing speed is kept, from 0 for bodies
+// that move on together to 1 for a perfectly elastic bounce
+// Bodies at the same spot or already moving apart are left alone, and false is returned
+func collide(a body, b body, restitution float64) bool {
+	aPosition, bPosition := a.GetPosition(), b.GetPosition()
+	normal := Velocity{bPosition.X - aPosition.X, bPosition.Y - aPosition.Y}
+	if normal.magnitude() == 0 {
+		return false
+	}
+	normal.normalize()
+
+	aVelocity, bVelocity := a.GetVelocity(), b.GetVelocity()
+	closing := (aVelocity.Dx-bVelocity.Dx)*normal.Dx + (aVelocity.Dy-bVelocity.Dy)*normal.Dy
+	if closing <= 0 {
+		return false
+	}
+
+	// Only the velocities along the normal change, so glancing blows only
+	// push as hard as the part of the hit that is head-on
+	aInverseMass, bInverseMass := 1/a.GetMass(), 1/b.GetMass()
+	impulse := (1 + restitution) * closing / (aInverseMass + bInverseMass)
+
+	aVelocity.Dx -= impulse * aInverseMass * normal.Dx
+	aVelocity.Dy -= impulse * aInverseMass * normal.Dy
+	bVelocity.Dx += impulse * bInverseMass * normal.Dx
+	bVelocity.Dy += impulse * bInverseMass * normal.Dy
+
+	a.setVelocity(aVelocity)
+	b.setVelocity(bVelocity)
+	return true
+}
+
+// mixRestitution returns the restitution of a collision between objects with
+// the given restitutions
+func mixRestitution(a float64, b float64) float64 {
+	return math.Sqrt(a * b)
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+// closeTo returns whether two velocities are equal, give or take rounding
+func closeTo(v1 Velocity, v2 Velocity) bool {
+	return math.Abs(v1.Dx-v2.Dx) < roundingError5SigFig && math.Abs(v1.Dy-v2.Dy) < roundingError5SigFig
+}
+
+func TestCollide(t *testing.T) {
+	testCases := []struct {
+		description   string
+		otherOffset   Position
+		velocity      Velocity
+		otherVelocity Velocity
+		heavier       bool
+		restitution   float64
+		collided      bool
+		expected      Velocity
+		otherExpected Velocity
+	}{
+		{"Head-on elastic", Position{40, 0}, Velocity{5, 0}, Velocity{-5, 0}, false, 1, true, Velocity{-5, 0}, Velocity{5, 0}},
+		{"Head-on inelastic", Position{40, 0}, Velocity{5, 0}, Velocity{-5, 0}, false, 0, true, Velocity{0, 0}, Velocity{0, 0}},
+		{"Hit from behind", Position{40, 0}, Velocity{5, 0}, Velocity{}, false, 1, true, Velocity{0, 0}, Velocity{5, 0}},
+		{"Glancing blow", Position{0, 40}, Velocity{5, 5}, Velocity{}, false, 1, true, Velocity{5, 0}, Velocity{0, 5}},
+		{"Heavier object", Position{40, 0}, Velocity{5, 0}, Velocity{}, true, 1, true, Velocity{2.5, 0}, Velocity{7.5, 0}},
+		{"Moving apart", Position{40, 0}, Velocity{-5, 0}, Velocity{5, 0}, false, 1, false, Velocity{-5, 0}, Velocity{5, 0}},
+		{"Same position", Position{0, 0}, Velocity{5, 0}, Velocity{}, false, 1, false, Velocity{5, 0}, Velocity{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			p := CreatePlayer(testNamePlayerTest, testColorPlayerTest, nil)
+			p.Position = centerPosPlayerTest
+			p.Velocity = tc.velocity
+			if tc.heavier {
+				p.AddEffect(HeavyEffect)
+			}
+
+			other := CreatePlayer(testNamePlayerTest, testColorPlayerTest, nil)
+			other.Position = Position{centerPosPlayerTest.X + tc.otherOffset.X, centerPosPlayerTest.Y + tc.otherOffset.Y}
+			other.Velocity = tc.otherVelocity
+
+			momentum := Velocity{
+				p.GetMass()*p.Velocity.Dx + other.GetMass()*other.Velocity.Dx,
+				p.GetMass()*p.Velocity.Dy + other.GetMass()*other.Velocity.Dy,
+			}
+
+			if collided := collide(p, other, tc.restitution); collided != tc.collided {
+				t.Errorf("Expected collided to be %v, got %v", tc.collided, collided)
+			}
+			if !closeTo(p.Velocity, tc.expected) || !closeTo(other.Velocity, tc.otherExpected) {
+				t.Errorf("Expected velocities %v and %v, got %v and %v", tc.expected, tc.otherExpected, p.Velocity, other.Velocity)
+			}
+
+			after := Velocity{
+				p.GetMass()*p.Velocity.Dx + other.GetMass()*other.Velocity.Dx,
+				p.GetMass()*p.Velocity.Dy + other.GetMass()*other.Velocity.Dy,
+			}
+			if !closeTo(momentum, after) {
+				t.Errorf("Expected momentum %v to be conserved, got %v", momentum, after)
+			}
+		})
+	}
+}
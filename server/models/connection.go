@@ -0,0 +1,412 @@
+package models
+
+import (
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrConnectionClosed is returned when sending through a closed connection
+var ErrConnectionClosed = errors.New("connection closed")
+
+// Connection is the transport messages to a player are sent through
+type Connection interface {
+	// Send delivers a message to the player
+	Send(m *Message) error
+	// Close ends the connection
+	Close() error
+	// CloseWith ends the connection, telling the client why with a WebSocket
+	// close code and reason
+	CloseWith(code int, reason string) error
+	// RemoteAddr describes where the player is connected from
+	RemoteAddr() string
+}
+
+// Outbound queue related constants
+const (
+	SendQueueSize = 64
+	MaxSendLag    = 5 * time.Second
+)
+
+// Heartbeat settings, overridden by the game configuration
+// Every connection is pinged each PingInterval, and a client that answers
+// nothing for PongTimeout is considered gone
+var (
+	PingInterval = 20 * time.Second
+	PongTimeout  = 45 * time.Second
+	WriteTimeout = 10 * time.Second
+)
+
+// ErrSlowClient is returned when a connection is closed because its client
+// could not keep up with the messages sent to it
+var ErrSlowClient = errors.New("client is too slow to keep up")
+
+// socket is the part of a WebSocket connection messages are written to
+type socket interface {
+	WriteMessage(messageType int, data []byte) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+	Subprotocol() string
+	RemoteAddr() net.Addr
+}
+
+// ConnectionStats counts the messages handled by a connection
+type ConnectionStats struct {
+	Queued  int    // messages waiting to be written
+	Sent    uint64 // messages written
+	Dropped uint64 // stale state updates replaced by newer ones before being written
+	Bytes   uint64 // bytes written
+}
+
+// frame is an encoded message waiting to be written
+type frame struct {
+	frameType   int
+	data        []byte
+	stateUpdate bool
+}
+
+// WebSocketConnection sends messages over a WebSocket, encoded with the codec
+// for the connection's subprotocol
+// Messages are encoded by the sender, since they may refer to live arena state,
+// then queued and written by the connection's own goroutine, so a slow client
+// never blocks the sender
+// A queued state update is replaced by a newer one, and a client that keeps
+// falling behind for MaxSendLag or fills its queue is disconnected
+type WebSocketConnection struct {
+	mutex       sync.Mutex
+	ws          socket
+	codec       Codec
+	queue       []*frame
+	stats       ConnectionStats
+	behindSince time.Time
+	closed      bool
+	closeFrame  []byte
+	wake        chan struct{}
+	done        chan struct{}
+}
+
+// CreateWebSocketConnection wraps an upgraded WebSocket connection and starts its writer
+func CreateWebSocketConnection(ws *websocket.Conn) *WebSocketConnection {
+	return createWebSocketConnection(ws)
+}
+
+func createWebSocketConnection(ws socket) *WebSocketConnection {
+	c := &WebSocketConnection{
+		ws:    ws,
+		codec: CodecFor(ws.Subprotocol()),
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	go c.write(time.NewTicker(PingInterval))
+	return c
+}
+
+// Send encodes the message and queues it to be written to the WebSocket
+// Messages that cannot be encoded are not queued, and their error is returned
+func (c *WebSocketConnection) Send(m *Message) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return ErrConnectionClosed
+	}
+
+	frameType, data, err := c.codec.Encode(m)
+	if err != nil {
+		return err
+	}
+	f := &frame{frameType: frameType, data: data, stateUpdate: isStateUpdate(m)}
+
+	if f.stateUpdate && c.dropStateUpdates() {
+		if c.behindSince.IsZero() {
+			c.behindSince = time.Now()
+		} else if time.Since(c.behindSince) > MaxSendLag {
+			c.evict()
+			return ErrSlowClient
+		}
+	}
+	if len(c.queue) >= SendQueueSize {
+		c.evict()
+		return ErrSlowClient
+	}
+
+	c.queue = append(c.queue, f)
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Close stops the connection from accepting messages
+// Messages already queued are still written before the WebSocket is closed
+func (c *WebSocketConnection) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.wake)
+	}
+	return nil
+}
+
+// CloseWith stops the connection from accepting messages and closes the
+// WebSocket with the given close code and reason once the queue is written
+func (c *WebSocketConnection) CloseWith(code int, reason string) error {
+	c.mutex.Lock()
+	if !c.closed {
+		c.closeFrame = websocket.FormatCloseMessage(code, reason)
+	}
+	c.mutex.Unlock()
+
+	return c.Close()
+}
+
+// RemoteAddr returns the address of the client
+func (c *WebSocketConnection) RemoteAddr() string {
+	return c.ws.RemoteAddr().String()
+}
+
+// Stats returns the connection's message counts
+func (c *WebSocketConnection) Stats() ConnectionStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := c.stats
+	stats.Queued = len(c.queue)
+	return stats
+}
+
+// dropStateUpdates removes queued state updates that were not written yet,
+// returning whether any were dropped
+// Clients only acknowledge snapshots they receive, so any later update can replace them
+func (c *WebSocketConnection) dropStateUpdates() bool {
+	kept := c.queue[:0]
+	for _, f := range c.queue {
+		if !f.stateUpdate {
+			kept = append(kept, f)
+		}
+	}
+	dropped := len(c.queue) - len(kept)
+	for i := len(kept); i < len(c.queue); i++ {
+		c.queue[i] = nil
+	}
+	c.queue = kept
+	c.stats.Dropped += uint64(dropped)
+	return dropped > 0
+}
+
+// evict discards the queue and closes the WebSocket without waiting for the writer
+func (c *WebSocketConnection) evict() {
+	log.Printf("Disconnecting slow client %s: %+v", c.ws.RemoteAddr(), c.stats)
+	c.queue = nil
+	c.closed = true
+	close(c.wake)
+	c.ws.Close()
+}
+
+// write writes queued messages until the connection is closed and its queue is
+// empty, pinging the client whenever PingInterval passes
+func (c *WebSocketConnection) write(ping *time.Ticker) {
+	defer close(c.done)
+	defer c.ws.Close()
+	defer ping.Stop()
+
+	for {
+		select {
+		case _, ok := <-c.wake:
+			if !ok {
+				if c.flush() {
+					c.writeCloseFrame()
+				}
+				return
+			}
+			if !c.flush() {
+				return
+			}
+		case <-ping.C:
+			if !c.ping() {
+				return
+			}
+		}
+	}
+}
+
+// ping asks the client for a pong, returning false if the ping could not be written
+func (c *WebSocketConnection) ping() bool {
+	c.ws.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	err := c.ws.WriteMessage(websocket.PingMessage, nil)
+	if err != nil {
+		log.Printf("error: %v", err)
+		c.fail()
+		return false
+	}
+	return true
+}
+
+// flush writes every queued message, returning false if a write failed
+func (c *WebSocketConnection) flush() bool {
+	for {
+		c.mutex.Lock()
+		if len(c.queue) == 0 {
+			c.behindSince = time.Time{}
+			c.mutex.Unlock()
+			return true
+		}
+		f := c.queue[0]
+		c.queue[0] = nil
+		c.queue = c.queue[1:]
+		c.mutex.Unlock()
+
+		c.ws.SetWriteDeadline(time.Now().Add(WriteTimeout))
+		err := c.ws.WriteMessage(f.frameType, f.data)
+		if err != nil {
+			log.Printf("error: %v", err)
+			c.fail()
+			return false
+		}
+
+		c.mutex.Lock()
+		c.stats.Sent++
+		c.stats.Bytes += uint64(len(f.data))
+		c.mutex.Unlock()
+	}
+}
+
+// writeCloseFrame tells the client why the connection is closed, if a reason was given
+func (c *WebSocketConnection) writeCloseFrame() {
+	c.mutex.Lock()
+	frame := c.closeFrame
+	c.mutex.Unlock()
+	if frame == nil {
+		return
+	}
+
+	c.ws.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	err := c.ws.WriteMessage(websocket.CloseMessage, frame)
+	if err != nil {
+		log.Printf("error: %v", err)
+	}
+}
+
+// fail stops the connection from accepting messages after a write failed
+func (c *WebSocketConnection) fail() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+	c.queue = nil
+}
+
+// isStateUpdate returns whether the message is a snapshot of the arena
+func isStateUpdate(m *Message) bool {
+	return m.Type == "update" || m.Type == "delta"
+}
+
+// MemoryConnection keeps every message sent through it, for tests, replays
+// and headless simulations
+type MemoryConnection struct {
+	mutex       sync.Mutex
+	messages    []Message
+	closed      bool
+	closeCode   int
+	closeReason string
+}
+
+// CreateMemoryConnection constructs an open in-memory connection
+func CreateMemoryConnection() *MemoryConnection {
+	return &MemoryConnection{}
+}
+
+// Send records the message
+func (c *MemoryConnection) Send(m *Message) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return ErrConnectionClosed
+	}
+	c.messages = append(c.messages, *m)
+	return nil
+}
+
+// Close stops the connection from accepting messages
+func (c *MemoryConnection) Close() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+	return nil
+}
+
+// CloseWith stops the connection from accepting messages and records the
+// close code and reason
+func (c *MemoryConnection) CloseWith(code int, reason string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		c.closeCode = code
+		c.closeReason = reason
+	}
+	return nil
+}
+
+// CloseReason returns the close code and reason the connection was closed
+// with, or 0 if it was closed without one
+func (c *MemoryConnection) CloseReason() (int, string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.closeCode, c.closeReason
+}
+
+// RemoteAddr identifies the connection as in-memory
+func (c *MemoryConnection) RemoteAddr() string {
+	return "memory"
+}
+
+// Messages returns a copy of the messages sent so far
+func (c *MemoryConnection) Messages() []Message {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]Message(nil), c.messages...)
+}
+
+// IsClosed returns whether the connection was closed
+func (c *MemoryConnection) IsClosed() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.closed
+}
+
+// NoopConnection discards every message, for players without a client such as bots
+type NoopConnection struct{}
+
+// Send discards the message
+func (NoopConnection) Send(m *Message) error {
+	return nil
+}
+
+// Close does nothing
+func (NoopConnection) Close() error {
+	return nil
+}
+
+// CloseWith does nothing
+func (NoopConnection) CloseWith(code int, reason string) error {
+	return nil
+}
+
+// RemoteAddr identifies the connection as having no client
+func (NoopConnection) RemoteAddr() string {
+	return "none"
+}
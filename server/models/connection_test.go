@@ -0,0 +1,197 @@
+package models
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testSocket records written frames, blocking each write until it is released
+type testSocket struct {
+	release chan struct{}
+	written chan []byte
+	closed  chan struct{}
+}
+
+func createTestSocket() *testSocket {
+	return &testSocket{
+		release: make(chan struct{}),
+		written: make(chan []byte, SendQueueSize),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (s *testSocket) WriteMessage(messageType int, data []byte) error {
+	select {
+	case <-s.release:
+	case <-s.closed:
+		return ErrConnectionClosed
+	}
+	s.written <- data
+	return nil
+}
+
+func (s *testSocket) SetWriteDeadline(t time.Time) error { return nil }
+func (s *testSocket) Subprotocol() string                { return JSONSubprotocol }
+func (s *testSocket) RemoteAddr() net.Addr               { return &net.IPAddr{} }
+
+func (s *testSocket) Close() error {
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	return nil
+}
+
+func TestSendDoesNotBlock(t *testing.T) {
+	s := createTestSocket()
+	c := createWebSocketConnection(s)
+	defer c.Close()
+
+	for i := 0; i < SendQueueSize; i++ {
+		err := c.Send(&Message{Type: "death"})
+		if err != nil {
+			t.Fatalf("Send %d failed while the client was not reading: %v", i, err)
+		}
+	}
+}
+
+func TestSendEncodesMessage(t *testing.T) {
+	s := createTestSocket()
+	close(s.release)
+	c := createWebSocketConnection(s)
+
+	// the arena keeps changing the state an update refers to after it is sent
+	phase := &PhaseMessage{Phase: "warmup"}
+	c.Send(&Message{Type: "phase", Data: phase})
+	phase.Phase = "results"
+	c.Close()
+	<-c.done
+
+	if data := <-s.written; !bytes.Contains(data, []byte("warmup")) {
+		t.Errorf("Expected the message as it was when sent, got %q", data)
+	}
+}
+
+func TestSendEncodeError(t *testing.T) {
+	s := createTestSocket()
+	close(s.release)
+	c := createWebSocketConnection(s)
+	defer c.Close()
+
+	if err := c.Send(&Message{Type: "rules", Data: func() {}}); err == nil {
+		t.Error("Expected an error sending a message that cannot be encoded")
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.queue) != 0 {
+		t.Errorf("Expected the message not to be queued, got %d queued", len(c.queue))
+	}
+}
+
+func TestStaleUpdatesAreDropped(t *testing.T) {
+	s := createTestSocket()
+	c := createWebSocketConnection(s)
+
+	c.Send(&Message{Type: "initial"})
+	for i := 0; i < 10; i++ {
+		c.Send(&Message{Type: "update", Data: &UpdateMessage{Snapshot: uint64(i)}})
+	}
+	c.Close()
+	close(s.release)
+	<-c.done
+
+	stats := c.Stats()
+	if stats.Dropped < 9 || stats.Sent+stats.Dropped != 11 {
+		t.Errorf("Expected all but the latest update to be dropped, got %+v", stats)
+	}
+}
+
+func TestFullQueueEvictsClient(t *testing.T) {
+	s := createTestSocket()
+	c := createWebSocketConnection(s)
+
+	var err error
+	for i := 0; i < 2*SendQueueSize && err == nil; i++ {
+		err = c.Send(&Message{Type: "death"})
+	}
+	if err != ErrSlowClient {
+		t.Fatalf("Expected a client with a full queue to be disconnected, got %v", err)
+	}
+	<-s.closed
+	<-c.done
+}
+
+func TestLaggingClientIsEvicted(t *testing.T) {
+	s := createTestSocket()
+	c := createWebSocketConnection(s)
+
+	// the writer may take the first update before blocking, so keep sending
+	// until an update was dropped because the client fell behind
+	for behind := false; !behind; {
+		err := c.Send(&Message{Type: "update"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.mutex.Lock()
+		behind = !c.behindSince.IsZero()
+		c.mutex.Unlock()
+	}
+
+	c.mutex.Lock()
+	c.behindSince = time.Now().Add(-2 * MaxSendLag)
+	c.mutex.Unlock()
+
+	err := c.Send(&Message{Type: "update"})
+	if err != ErrSlowClient {
+		t.Fatalf("Expected a client that stayed behind to be disconnected, got %v", err)
+	}
+	<-s.closed
+	<-c.done
+}
+
+func TestIdleConnectionIsPinged(t *testing.T) {
+	interval := PingInterval
+	PingInterval = 10 * time.Millisecond
+	defer func() { PingInterval = interval }()
+
+	s := createTestSocket()
+	close(s.release)
+	c := createWebSocketConnection(s)
+
+	select {
+	case data := <-s.written:
+		if len(data) != 0 {
+			t.Errorf("Expected an empty ping, got %q", data)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected an idle connection to be pinged")
+	}
+	c.Close()
+	<-c.done
+}
+
+func TestCloseWithError(t *testing.T) {
+	s := createTestSocket()
+	close(s.release)
+	c := createWebSocketConnection(s)
+
+	err := CloseWithError(c, ErrorIdle, "no input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-c.done
+
+	want := websocket.FormatCloseMessage(CloseIdle, ErrorIdle)
+	var frames [][]byte
+	for len(s.written) > 0 {
+		frames = append(frames, <-s.written)
+	}
+	if len(frames) != 2 || !bytes.Contains(frames[0], []byte(ErrorIdle)) || !bytes.Equal(frames[1], want) {
+		t.Errorf("Expected an error message followed by a close frame, got %q", frames)
+	}
+}
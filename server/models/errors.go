@@ -0,0 +1,67 @@
+package models
+
+import "github.com/gorilla/websocket"
+
+// Error codes sent to clients in error messages and as the reason of close frames
+const (
+	ErrorInvalidMessage = "invalid_message"
+	ErrorUnknownMessage = "unknown_message"
+	ErrorInvalidName    = "invalid_name"
+	ErrorInvalidCountry = "invalid_country"
+	ErrorAlreadySpawned = "already_spawned"
+	ErrorSpawnFailed    = "spawn_failed"
+	ErrorArenaFull      = "arena_full"
+	ErrorRateLimited    = "rate_limited"
+	ErrorKicked         = "kicked"
+	ErrorIdle           = "idle"
+	ErrorServerShutdown = "server_shutdown"
+	ErrorInternal       = "internal_error"
+)
+
+// WebSocket close codes clients are disconnected with
+// Codes from 4000 to 4999 are reserved for applications
+const (
+	CloseArenaFull   = 4001
+	CloseRateLimited = 4002
+	CloseKicked      = 4003
+	CloseIdle        = 4004
+)
+
+var closeCodes = map[string]int{
+	ErrorArenaFull:      CloseArenaFull,
+	ErrorRateLimited:    CloseRateLimited,
+	ErrorKicked:         CloseKicked,
+	ErrorIdle:           CloseIdle,
+	ErrorServerShutdown: websocket.CloseGoingAway,
+	ErrorInternal:       websocket.CloseInternalServerErr,
+}
+
+// CloseCode returns the WebSocket close code for a client disconnected with
+// the given error code
+func CloseCode(code string) int {
+	if closeCode, ok := closeCodes[code]; ok {
+		return closeCode
+	}
+	return websocket.ClosePolicyViolation
+}
+
+// CreateErrorMessage constructs an error message for the client
+func CreateErrorMessage(code string, message string) *Message {
+	return &Message{
+		Type: "error",
+		Data: &ErrorMessage{
+			Code:    code,
+			Message: message,
+		},
+	}
+}
+
+// CloseWithError tells the client why it is being disconnected and closes its
+// connection with the matching close code
+func CloseWithError(conn Connection, code string, message string) error {
+	err := conn.Send(CreateErrorMessage(code, message))
+	if err != nil {
+		return err
+	}
+	return conn.CloseWith(CloseCode(code), code)
+}
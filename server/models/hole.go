@@ -3,21 +3,29 @@ package models
 import (
 	"math"
 	"math/rand"
+
+	"github.com/rs/xid"
 )
 
 // Hole related constants
 const (
-	MinHoleRadius       = 15
-	MaxHoleRadius       = 45
-	gravityRadiusFactor = 5
-	HzToSeconds         = 60
-	MinHoleLife         = 25 * HzToSeconds
-	MaxHoleLife         = 75 * HzToSeconds
-	HoleInfancy         = 2 * HzToSeconds
+	HzToSeconds = 60
+)
+
+// Hole settings, overridden by the game configuration
+// Lifetimes are measured in base ticks
+var (
+	MinHoleRadius       = 15.0
+	MaxHoleRadius       = 45.0
+	GravityRadiusFactor = 5.0
+	MinHoleLife         = 25.0 * HzToSeconds
+	MaxHoleLife         = 75.0 * HzToSeconds
+	HoleInfancy         = 2.0 * HzToSeconds
 )
 
 // Hole contains the data for a hole's position and size
 type Hole struct {
+	ID            string   `json:"id"`
 	Position      Position `json:"position"`
 	Radius        float64  `json:"radius"`
 	GravityRadius float64  `json:"-"`
@@ -31,9 +39,10 @@ func CreateHole(position Position) *Hole {
 	life := math.Floor(rand.Float64()*((MaxHoleLife-MinHoleLife)+1)) + MinHoleLife
 	radius := math.Floor(rand.Float64()*((MaxHoleRadius-MinHoleRadius)+1)) + MinHoleRadius
 	h := Hole{
+		ID:            xid.New().String(),
 		Position:      position,
 		Radius:        radius,
-		GravityRadius: radius * gravityRadiusFactor,
+		GravityRadius: radius * GravityRadiusFactor,
 		Life:          life,
 		IsAlive:       false,
 		StartingLife:  life,
@@ -43,7 +52,7 @@ func CreateHole(position Position) *Hole {
 
 // GetID returns the hole's ID
 func (h Hole) GetID() string {
-	return ""
+	return h.ID
 }
 
 // GetColor returns this hole's color
@@ -61,6 +70,11 @@ func (h Hole) GetVelocity() Velocity {
 	return Velocity{}
 }
 
+// GetMass returns 0, holes are never bumped
+func (h Hole) GetMass() float64 {
+	return 0
+}
+
 // GetRadius returns this hole's radius
 func (h Hole) GetRadius() float64 {
 	return h.Radius
@@ -96,17 +110,19 @@ func (h *Hole) setGravityRadius(gravityRadius float64) {
 }
 
 // Update reduces this holes life and increases radius if max not reached
+// Life is measured in base ticks, so a hole lasts as long at any tick rate
 func (h *Hole) Update() {
+	scale := timeScale()
 	hLife := h.getLife()
-	hLife--
+	hLife -= scale
 	h.setLife(hLife)
 
 	if hLife < h.getStartingLife()-HoleInfancy {
 		h.setIsAlive(true)
 	}
 	if hRadius := h.GetRadius(); hRadius < MaxHoleRadius*1.2 {
-		h.setRadius(hRadius + 0.02)
-		h.setGravityRadius(h.GetGravityRadius() + 0.03)
+		h.setRadius(hRadius + 0.02*scale)
+		h.setGravityRadius(h.GetGravityRadius() + 0.03*scale)
 	}
 }
 
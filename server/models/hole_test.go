@@ -24,7 +24,7 @@ func TestCreateHole(t *testing.T) {
 	if h.Position.Y != 10 {
 		t.Error("Y position is not set correctly")
 	}
-	if h.GravityRadius != h.Radius*gravityRadiusFactor {
+	if h.GravityRadius != h.Radius*GravityRadiusFactor {
 		t.Error("Gravity radius is calculated incorrectly")
 	}
 }
@@ -81,8 +81,8 @@ func TestHoleLifeCycle(t *testing.T) {
 		wantIsDead bool // means that hole dies and starts a new life if false
 	}{
 		{MinHoleLife, 1, false},
-		{MinHoleLife, MinHoleLife - 1, false},
-		{MinHoleLife, MinHoleLife + 1, true},
+		{MinHoleLife, int(MinHoleLife) - 1, false},
+		{MinHoleLife, int(MinHoleLife) + 1, true},
 	}
 
 	for _, tc := range testCases {
@@ -107,3 +107,27 @@ func TestHoleLifeCycle(t *testing.T) {
 		})
 	}
 }
+
+func TestHoleTickRate(t *testing.T) {
+	defer func() { TickRate = BaseTickRate }()
+
+	for _, tickRate := range []float64{30, 60, 120} {
+		t.Run(fmt.Sprintf("Test hole lifetime at %g Hz", tickRate), func(t *testing.T) {
+			TickRate = tickRate
+			h := Hole{Radius: 20, Life: MinHoleLife, StartingLife: MinHoleLife}
+
+			// MinHoleLife is in base ticks, so the hole should die after that many seconds
+			seconds := int(MinHoleLife) / BaseTickRate
+			for i := 0; i < seconds*int(tickRate); i++ {
+				if h.IsDead() {
+					t.Fatalf("Hole died after %d of %d ticks", i, seconds*int(tickRate))
+				}
+				h.Update()
+			}
+			h.Update()
+			if !h.IsDead() {
+				t.Errorf("Hole outlived its lifetime of %d seconds", seconds)
+			}
+		})
+	}
+}
@@ -2,21 +2,23 @@ package models
 
 import (
 	"math"
+
+	"github.com/rs/xid"
 )
 
-// Junk related constants
-const (
-	JunkFriction        = 0.99
-	MinimumBump         = 0.5
-	BumpFactor          = 1.05
-	JunkRadius          = 11
-	JunkDebounceTicks   = 15
-	JunkVTransferFactor = 0.5
-	JunkGravityDamping  = 0.025
+// Junk physics settings, overridden by the game configuration
+var (
+	JunkFriction       = 0.99
+	JunkMass           = 0.25
+	JunkRestitution    = 0.5
+	JunkRadius         = 11.0
+	JunkDebounceTicks  = 15
+	JunkGravityDamping = 0.025
 )
 
 // Junk a position and velocity struct describing it's state and player struct to identify rewarding points
 type Junk struct {
+	ID            string   `json:"id"`
 	Position      Position `json:"position"`
 	Velocity      Velocity `json:"-"`
 	Color         string   `json:"color"`
@@ -28,6 +30,7 @@ type Junk struct {
 // CreateJunk initializes and returns an instance of a Junk
 func CreateJunk(position Position) *Junk {
 	return &Junk{
+		ID:        xid.New().String(),
 		Position:  position,
 		Velocity:  Velocity{0, 0},
 		Color:     "white",
@@ -38,7 +41,7 @@ func CreateJunk(position Position) *Junk {
 
 // GetID returns the ID of this junk
 func (j Junk) GetID() string {
-	return ""
+	return j.ID
 }
 
 // GetColor returns the color of this junk
@@ -61,6 +64,11 @@ func (j Junk) GetRadius() float64 {
 	return JunkRadius
 }
 
+// GetMass returns the mass of this junk
+func (j Junk) GetMass() float64 {
+	return JunkMass
+}
+
 func (j *Junk) getDebounce() int {
 	return j.Debounce
 }
@@ -95,20 +103,22 @@ func (j *Junk) setLastPlayerHit(player *Player) {
 
 // UpdatePosition Update Junk's position based on calculations of position/velocity
 func (j *Junk) UpdatePosition(height float64, width float64) {
+	scale := timeScale()
 	positionVector := j.GetPosition()
 	velocityVector := j.GetVelocity()
-	if positionVector.X+velocityVector.Dx > width-JunkRadius || positionVector.X+velocityVector.Dx < JunkRadius {
+	if positionVector.X+velocityVector.Dx*scale > width-JunkRadius || positionVector.X+velocityVector.Dx*scale < JunkRadius {
 		velocityVector.Dx = -velocityVector.Dx
 	}
-	if positionVector.Y+velocityVector.Dy > height-JunkRadius || positionVector.Y+velocityVector.Dy < JunkRadius {
+	if positionVector.Y+velocityVector.Dy*scale > height-JunkRadius || positionVector.Y+velocityVector.Dy*scale < JunkRadius {
 		velocityVector.Dy = -velocityVector.Dy
 	}
 
-	velocityVector.Dx *= JunkFriction
-	velocityVector.Dy *= JunkFriction
+	friction := math.Pow(JunkFriction, scale)
+	velocityVector.Dx *= friction
+	velocityVector.Dy *= friction
 
-	positionVector.X += velocityVector.Dx
-	positionVector.Y += velocityVector.Dy
+	positionVector.X += velocityVector.Dx * scale
+	positionVector.Y += velocityVector.Dy * scale
 
 	j.setPosition(positionVector)
 	j.setVelocity(velocityVector)
@@ -126,57 +136,34 @@ func (j *Junk) UpdatePosition(height float64, width float64) {
 	}
 }
 
-// HitBy Update Junks's velocity based on calculations of being hit by a player
+// HitBy bounces the junk off a player that touched it along the line between
+// their centres, and gives the junk to the player
+// Junk is lighter than players, so it flies off faster than the player that hit it
+// Players only take junk they actually push, not junk moving away from them
 func (j *Junk) HitBy(p *Player) {
-	pVelocity := p.GetVelocity()
-	jVelocity := j.GetVelocity()
 	// We don't want this collision till the debounce is down.
 	if j.getDebounce() != 0 {
 		return
 	}
 
+	if !collide(p, j, mixRestitution(PlayerRestitution, JunkRestitution)) {
+		return
+	}
 	j.setColor(p.GetColor()) //Assign junk to last recently hit player color
 	j.setLastPlayerHit(p)
-
-	if pVelocity.Dx < 0 {
-		jVelocity.Dx = math.Min(pVelocity.Dx*BumpFactor, -MinimumBump)
-	} else {
-		jVelocity.Dx = math.Max(pVelocity.Dx*BumpFactor, MinimumBump)
-	}
-
-	if pVelocity.Dy < 0 {
-		jVelocity.Dy = math.Min(pVelocity.Dy*BumpFactor, -MinimumBump)
-	} else {
-		jVelocity.Dy = math.Max(pVelocity.Dy*BumpFactor, MinimumBump)
-	}
-
-	j.setVelocity(jVelocity)
-	p.hitJunk()
-	j.setDebounce(JunkDebounceTicks)
+	j.setDebounce(scaleTicks(JunkDebounceTicks))
 }
 
-// HitJunk Update Junks's velocity based on calculations of being hit by another Junk
+// HitJunk bounces the junk off another junk it touched along the line between their centres
 func (j *Junk) HitJunk(jh *Junk) {
 	// We don't want this collision till the debounce is down.
 	if j.getJDebounce() != 0 {
 		return
 	}
 
-	jInitialVelocity := j.GetVelocity()
-	jVelocity := jInitialVelocity
-	jhVelocity := jh.GetVelocity()
-	//Calculate this junks's new velocity
-	jVelocity.Dx = (jVelocity.Dx * -JunkVTransferFactor) + (jhVelocity.Dx * JunkVTransferFactor)
-	jVelocity.Dy = (jVelocity.Dy * -JunkVTransferFactor) + (jhVelocity.Dy * JunkVTransferFactor)
-
-	//Calculate other junk's new velocity
-	jhVelocity.Dx = (jhVelocity.Dx * -JunkVTransferFactor) + (jInitialVelocity.Dx * JunkVTransferFactor)
-	jhVelocity.Dy = (jhVelocity.Dy * -JunkVTransferFactor) + (jInitialVelocity.Dy * JunkVTransferFactor)
-
-	j.setVelocity(jVelocity)
-	jh.setVelocity(jhVelocity)
-	j.setJDebounce(JunkDebounceTicks)
-	jh.setJDebounce(JunkDebounceTicks)
+	collide(j, jh, JunkRestitution)
+	j.setJDebounce(scaleTicks(JunkDebounceTicks))
+	jh.setJDebounce(scaleTicks(JunkDebounceTicks))
 }
 
 // ApplyGravity applys a vector towards given position
@@ -192,7 +179,27 @@ func (j *Junk) ApplyGravity(h *Hole) {
 	gravityVector.normalize()
 
 	//Velocity is affected by how close you are, the size of the hole, and a damping factor.
-	jVelocity.Dx += gravityVector.Dx * inverseMagnitude * h.GetRadius() * JunkGravityDamping
-	jVelocity.Dy += gravityVector.Dy * inverseMagnitude * h.GetRadius() * JunkGravityDamping
+	jVelocity.Dx += gravityVector.Dx * inverseMagnitude * h.GetRadius() * JunkGravityDamping * timeScale()
+	jVelocity.Dy += gravityVector.Dy * inverseMagnitude * h.GetRadius() * JunkGravityDamping * timeScale()
+	j.setVelocity(jVelocity)
+}
+
+// AttractTo pulls the junk towards a player with the magnet effect, more
+// strongly the closer it is
+func (j *Junk) AttractTo(p *Player) {
+	jPosition := j.GetPosition()
+	pPosition := p.GetPosition()
+
+	pull := Velocity{pPosition.X - jPosition.X, pPosition.Y - jPosition.Y}
+	distance := pull.magnitude()
+	if distance == 0 || distance > MagnetRadius {
+		return
+	}
+	pull.normalize()
+
+	strength := MagnetStrength * (1 - distance/MagnetRadius) * timeScale()
+	jVelocity := j.GetVelocity()
+	jVelocity.Dx += pull.Dx * strength
+	jVelocity.Dy += pull.Dy * strength
 	j.setVelocity(jVelocity)
 }
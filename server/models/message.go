@@ -7,17 +7,101 @@ type Message struct {
 }
 
 // ConnectionMessage defines the initial connection message
+// TickRate lets clients interpolate between snapshots, which are numbered by tick
+// Token lets the client resume its player if its connection drops
+// Teams lists the teams in team games, and Team is the player's team once it has spawned
 type ConnectionMessage struct {
 	ArenaWidth  float64 `json:"arenaWidth"`
 	ArenaHeight float64 `json:"arenaHeight"`
 	PlayerID    string  `json:"playerID"`
+	TickRate    float64 `json:"tickRate"`
+	Token       string  `json:"token"`
+	Team        int     `json:"team,omitempty"`
+	Teams       []Team  `json:"teams,omitempty"`
+}
+
+// RulesMessage defines the rules clients need to know about
+// It is sent whenever the rules of a running game are reloaded
+type RulesMessage struct {
+	ArenaWidth      float64 `json:"arenaWidth"`
+	ArenaHeight     float64 `json:"arenaHeight"`
+	PlayerRadius    float64 `json:"playerRadius"`
+	JunkRadius      float64 `json:"junkRadius"`
+	PointsPerJunk   int     `json:"pointsPerJunk"`
+	PointsPerPlayer int     `json:"pointsPerPlayer"`
+}
+
+// ErrorMessage tells a client why its request failed or why it is being disconnected
+// Code is one of the Error constants, Message describes the problem for people
+// An ErrorMessage is also an error, for errors whose code a client should be told
+type ErrorMessage struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error returns the description of the problem
+func (e *ErrorMessage) Error() string {
+	return e.Message
+}
+
+// PhaseMessage tells clients which phase the match is in and how many seconds
+// are left in it
+// Remaining is 0 while the phase waits for players instead of a timer
+type PhaseMessage struct {
+	Phase     string  `json:"phase"`
+	Remaining float64 `json:"remaining"`
+}
+
+// ResultsMessage defines the final standings of a match
+// Standings are ordered from the most points to the fewest, and so are Teams in team games
+type ResultsMessage struct {
+	Winner    string     `json:"winner"`
+	Standings []Standing `json:"standings"`
+	Teams     []Team     `json:"teams,omitempty"`
+}
+
+// Standing is a player's final score in a match
+type Standing struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Country string `json:"country"`
+	Team    int    `json:"team,omitempty"`
+	Points  int    `json:"points"`
 }
 
 // UpdateMessage defines the schema for a state update message
+// A full update is a keyframe containing every object in the arena
+// Snapshot is the tick the state was captured at
+// Teams holds every team's score in team games
 type UpdateMessage struct {
-	Holes   []*Hole   `json:"holes"`
-	Junk    []*Junk   `json:"junk"`
-	Players []*Player `json:"players"`
+	Snapshot uint64     `json:"snapshot"`
+	Input    InputAck   `json:"input"`
+	Holes    []*Hole    `json:"holes"`
+	Junk     []*Junk    `json:"junk"`
+	Players  []*Player  `json:"players"`
+	PowerUps []*PowerUp `json:"powerUps"`
+	Teams    []Team     `json:"teams,omitempty"`
+}
+
+// DeltaMessage defines the schema for a delta state update message
+// It contains only the objects that were added or changed since the baseline
+// snapshot acknowledged by the client, and the IDs of objects that were removed
+// Teams is only sent when a team's score changed since the baseline
+type DeltaMessage struct {
+	Snapshot uint64     `json:"snapshot"`
+	Baseline uint64     `json:"baseline"`
+	Input    InputAck   `json:"input"`
+	Holes    []*Hole    `json:"holes"`
+	Junk     []*Junk    `json:"junk"`
+	Players  []*Player  `json:"players"`
+	PowerUps []*PowerUp `json:"powerUps"`
+	Removed  []string   `json:"removed"`
+	Teams    []Team     `json:"teams,omitempty"`
+}
+
+// AckMessage defines a client acknowledgement of a received snapshot
+type AckMessage struct {
+	Snapshot uint64 `json:"snapshot"`
 }
 
 // SpawnHandlerMessage defines a spawn message
@@ -27,7 +111,17 @@ type SpawnHandlerMessage struct {
 }
 
 // KeyHandlerMessage defines a player key press message
+// Sequence increases with every input a client sends
 type KeyHandlerMessage struct {
-	Key       int  `json:"key"`
-	IsPressed bool `json:"isPressed"`
+	Key       int    `json:"key"`
+	IsPressed bool   `json:"isPressed"`
+	Sequence  uint64 `json:"sequence"`
+}
+
+// InputAck tells a client the last input the server processed for its player
+// and the player's resulting velocity, so the client can reconcile its predicted
+// position by replaying newer inputs from the server state
+type InputAck struct {
+	Sequence uint64   `json:"sequence"`
+	Velocity Velocity `json:"velocity"`
 }
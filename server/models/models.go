@@ -4,6 +4,14 @@ import (
 	"math"
 )
 
+// BaseTickRate is the simulation rate in Hz that per tick constants are tuned for
+const BaseTickRate = HzToSeconds
+
+// TickRate is the rate in Hz the simulation is stepped at
+// Per tick movement, friction, growth and lifetimes are scaled by the time
+// that passes in a tick, so the game plays the same at any tick rate
+var TickRate float64 = BaseTickRate
+
 // Object represents an interactable object on the Arena
 type Object interface {
 	GetID() string
@@ -11,6 +19,7 @@ type Object interface {
 	GetPosition() Position
 	GetVelocity() Velocity
 	GetRadius() float64
+	GetMass() float64
 }
 
 // Position x y position
@@ -36,3 +45,13 @@ func (v *Velocity) normalize() {
 		v.Dy /= mag
 	}
 }
+
+// timeScale returns the number of base ticks that pass in one tick
+func timeScale() float64 {
+	return BaseTickRate / TickRate
+}
+
+// scaleTicks converts a duration in base ticks to a number of ticks
+func scaleTicks(baseTicks int) int {
+	return int(math.Ceil(float64(baseTicks) / timeScale()))
+}
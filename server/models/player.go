@@ -3,30 +3,37 @@ package models
 import (
 	"log"
 	"math"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/rs/xid"
 )
 
 // Player related constants
 const (
-	LeftKey                = 37
-	RightKey               = 39
-	UpKey                  = 38
-	DownKey                = 40
-	JunkBounceFactor       = -0.25
-	VelocityTransferFactor = 0.75
-	WallBounceFactor       = -1.5
-	PlayerRadius           = 25
-	PlayerAcceleration     = 0.5
-	PlayerFriction         = 0.97
-	MaxVelocity            = 15
-	PointsPerJunk          = 100
-	PointsPerPlayer        = 500
-	gravityDamping         = 0.075
-	PlayerDebounceTicks    = 15
-	PointsDebounceTicks    = 100
+	LeftKey  = 37
+	RightKey = 39
+	UpKey    = 38
+	DownKey  = 40
+)
+
+// Player physics and scoring settings, overridden by the game configuration
+var (
+	PlayerMass           = 1.0
+	PlayerRestitution    = 0.9
+	WallBounceFactor     = -1.5
+	PlayerRadius         = 25.0
+	PlayerAcceleration   = 0.5
+	PlayerFriction       = 0.97
+	MaxVelocity          = 15.0
+	PointsPerJunk        = 100
+	PointsPerPlayer      = 500
+	TeamKillPenalty      = 500
+	PlayerGravityDamping = 0.075
+	PlayerDebounceTicks  = 15
+	PointsDebounceTicks  = 100
 )
 
 // KeysPressed contains a boolean about each key, true if it's down
@@ -39,25 +46,34 @@ type KeysPressed struct {
 
 // Player contains data and state about a player's object
 type Player struct {
-	Name           string      `json:"name"`
-	ID             string      `json:"id"`
-	Country        string      `json:"country"`
-	Position       Position    `json:"position"`
-	Velocity       Velocity    `json:"-"`
-	Color          string      `json:"color"`
-	Angle          float64     `json:"angle"`
-	Controls       KeysPressed `json:"-"`
-	Points         int         `json:"points"`
-	LastPlayerHit  *Player     `json:"-"`
+	lastInput      uint64         // accessed atomically, kept first for 64-bit alignment
+	Name           string         `json:"name"`
+	ID             string         `json:"id"`
+	Country        string         `json:"country"`
+	Position       Position       `json:"position"`
+	Velocity       Velocity       `json:"-"`
+	Color          string         `json:"color"`
+	Angle          float64        `json:"angle"`
+	Controls       KeysPressed    `json:"-"`
+	Points         int            `json:"points"`
+	Team           int            `json:"team,omitempty"`
+	Effects        []ActiveEffect `json:"effects,omitempty"`
+	LastPlayerHit  *Player        `json:"-"`
 	pointsDebounce int
 	pDebounce      int
 	rwMutex        sync.RWMutex
-	ws             *websocket.Conn
+	conn           Connection
+	lastActive     time.Time
 }
 
 // CreatePlayer constructs an instance of player with
-// given position, color, and WebSocket connection
-func CreatePlayer(name string, color string, ws *websocket.Conn) *Player {
+// given position, color, and connection
+// Messages to a player without a connection are discarded
+func CreatePlayer(name string, color string, conn Connection) *Player {
+	if conn == nil {
+		conn = NoopConnection{}
+	}
+
 	return &Player{
 		Name:           name,
 		ID:             xid.New().String(),
@@ -68,41 +84,55 @@ func CreatePlayer(name string, color string, ws *websocket.Conn) *Player {
 		Controls:       KeysPressed{},
 		pDebounce:      0,
 		pointsDebounce: 0,
-		rwMutex:        sync.RWMutex{},
-		ws:             ws,
+		conn:           conn,
+		lastActive:     time.Now(),
 	}
 }
 
 // GetID returns the ID of the player
-func (p Player) GetID() string {
+func (p *Player) GetID() string {
 	return p.ID
 }
 
 // GetColor returns the color of the player
-func (p Player) GetColor() string {
+func (p *Player) GetColor() string {
 	return p.Color
 }
 
 // GetPosition returns the position of the player
-func (p Player) GetPosition() Position {
+func (p *Player) GetPosition() Position {
 	return p.Position
 }
 
 // GetVelocity returns the velocity of the player
-func (p Player) GetVelocity() Velocity {
+func (p *Player) GetVelocity() Velocity {
 	return p.Velocity
 }
 
 // GetRadius returns the radius of the player
-func (p Player) GetRadius() float64 {
+func (p *Player) GetRadius() float64 {
 	return PlayerRadius
 }
 
+// GetMass returns the mass of the player, raised by HeavyMassFactor while the
+// player has the heavy effect
+func (p *Player) GetMass() float64 {
+	if p.HasEffect(HeavyEffect) {
+		return PlayerMass * HeavyMassFactor
+	}
+	return PlayerMass
+}
+
 // GetName returns name of player
-func (p Player) GetName() string {
+func (p *Player) GetName() string {
 	return p.Name
 }
 
+// GetTeam returns the ID of the player's team, 0 if it plays for itself
+func (p *Player) GetTeam() int {
+	return p.Team
+}
+
 func (p *Player) getControls() KeysPressed {
 	return p.Controls
 }
@@ -155,38 +185,99 @@ func (p *Player) setLastPlayerHit(playerHit *Player) {
 	p.LastPlayerHit = playerHit
 }
 
+// GetLastInput returns the sequence number of the last input processed for the player
+func (p *Player) GetLastInput() uint64 {
+	return atomic.LoadUint64(&p.lastInput)
+}
+
+// SetLastInput records the sequence number of an input processed for the player
+// Sequence numbers older than the last recorded input are ignored
+func (p *Player) SetLastInput(sequence uint64) {
+	for {
+		last := atomic.LoadUint64(&p.lastInput)
+		if sequence <= last || atomic.CompareAndSwapUint64(&p.lastInput, last, sequence) {
+			return
+		}
+	}
+}
+
 // AddPoints adds numPoints to player p
 func (p *Player) AddPoints(numPoints int) {
 	p.setPoints(p.Points + numPoints)
 }
 
-// SendJSON sends JSON data through the player's websocket connection
-func (p *Player) SendJSON(m *Message) error {
+// GetConnection returns the connection messages to the player are sent through
+func (p *Player) GetConnection() Connection {
+	p.rwMutex.RLock()
+	defer p.rwMutex.RUnlock()
+	return p.conn
+}
+
+// SetConnection changes the connection messages to the player are sent through
+func (p *Player) SetConnection(conn Connection) {
+	p.rwMutex.Lock()
+	defer p.rwMutex.Unlock()
+	p.conn = conn
+}
+
+// GetLastActive returns when the player's client last controlled it
+func (p *Player) GetLastActive() time.Time {
+	p.rwMutex.RLock()
+	defer p.rwMutex.RUnlock()
+	return p.lastActive
+}
+
+// SetLastActive records when the player's client last controlled it
+func (p *Player) SetLastActive(t time.Time) {
 	p.rwMutex.Lock()
 	defer p.rwMutex.Unlock()
+	p.lastActive = t
+}
 
-	return p.ws.WriteJSON(m)
+// Send sends a message through the player's connection
+func (p *Player) Send(m *Message) error {
+	return p.GetConnection().Send(m)
 }
 
-// Close ends the WebSocket connection with the player
+// Close ends the connection with the player
 func (p *Player) Close() {
-	err := p.ws.Close()
+	err := p.GetConnection().Close()
 	if err != nil {
 		log.Printf("Failed to close connection:\n%v", err)
 	}
 }
 
+// CloseWith ends the connection with the player, telling its client why with
+// a WebSocket close code and reason
+func (p *Player) CloseWith(code int, reason string) {
+	err := p.GetConnection().CloseWith(code, reason)
+	if err != nil {
+		log.Printf("Failed to close connection:\n%v", err)
+	}
+}
+
+// CloseWithError tells the player's client why it is being disconnected and
+// closes its connection
+func (p *Player) CloseWithError(code string, message string) {
+	err := CloseWithError(p.GetConnection(), code, message)
+	if err != nil {
+		log.Printf("error: %v", err)
+		p.Close()
+	}
+}
+
 // UpdatePosition based on calculations of position/velocity
 func (p *Player) UpdatePosition(height float64, width float64) {
 
 	controlsVector := Velocity{0, 0}
+	scale := timeScale()
 
 	if p.getControls().Left {
-		p.setAngle(math.Mod(p.getAngle()+0.1, 360))
+		p.setAngle(math.Mod(p.getAngle()+0.1*scale, 360))
 	}
 
 	if p.getControls().Right {
-		p.setAngle(math.Mod(p.getAngle()-0.1, 360))
+		p.setAngle(math.Mod(p.getAngle()-0.1*scale, 360))
 	}
 
 	if p.getControls().Up {
@@ -195,27 +286,30 @@ func (p *Player) UpdatePosition(height float64, width float64) {
 	}
 
 	controlsVector.normalize()
-	controlsVector.Dx *= PlayerAcceleration
-	controlsVector.Dy *= PlayerAcceleration
+	acceleration := p.boosted(PlayerAcceleration)
+	controlsVector.Dx *= acceleration * scale
+	controlsVector.Dy *= acceleration * scale
 
 	positionVector := p.GetPosition()
 	velocityVector := p.GetVelocity()
-	velocityVector.Dx = (velocityVector.Dx * PlayerFriction) + controlsVector.Dx
-	velocityVector.Dy = (velocityVector.Dy * PlayerFriction) + controlsVector.Dy
+	friction := math.Pow(PlayerFriction, scale)
+	velocityVector.Dx = (velocityVector.Dx * friction) + controlsVector.Dx
+	velocityVector.Dy = (velocityVector.Dy * friction) + controlsVector.Dy
 
 	// Ensure it never gets going too fast
-	if velocityVector.magnitude() > MaxVelocity {
+	maxVelocity := p.boosted(MaxVelocity)
+	if velocityVector.magnitude() > maxVelocity {
 		velocityVector.normalize()
-		velocityVector.Dx *= MaxVelocity
-		velocityVector.Dy *= MaxVelocity
+		velocityVector.Dx *= maxVelocity
+		velocityVector.Dy *= maxVelocity
 	}
 
 	// Apply player's velocity vector
 	p.setVelocity(velocityVector)
 
 	// Calculate next position
-	positionVector.X = positionVector.X + velocityVector.Dx
-	positionVector.Y = positionVector.Y + velocityVector.Dy
+	positionVector.X = positionVector.X + velocityVector.Dx*scale
+	positionVector.Y = positionVector.Y + velocityVector.Dy*scale
 
 	// Set position
 	p.setPosition(positionVector)
@@ -234,40 +328,24 @@ func (p *Player) UpdatePosition(height float64, width float64) {
 		p.setLastPlayerHit(nil)
 		p.setPointsDebounce(0)
 	}
-}
 
-func (p *Player) hitJunk() {
-	velocityVector := p.GetVelocity()
-	velocityVector.Dx *= JunkBounceFactor
-	velocityVector.Dy *= JunkBounceFactor
-	p.setVelocity(velocityVector)
+	p.updateEffects()
 }
 
-// HitPlayer calculates collision, update Player's velocity based on calculation of hitting another player
+// HitPlayer bounces the player off another player it touched along the line
+// between their centres
+// A heavier player is knocked back less and knocks the other player back more
 func (p *Player) HitPlayer(ph *Player) {
 	if p.getPDebounce() != 0 {
 		return
 	}
 
-	pInitialVelocity := p.GetVelocity()
-	pVelocity := pInitialVelocity
-	phVelocity := ph.GetVelocity()
-
-	//Calculate player's new velocity
-	pVelocity.Dx = (pVelocity.Dx * -VelocityTransferFactor) + (phVelocity.Dx * VelocityTransferFactor)
-	pVelocity.Dy = (pVelocity.Dy * -VelocityTransferFactor) + (phVelocity.Dy * VelocityTransferFactor)
-
-	//Calculate hit player's new velocity
-	phVelocity.Dx = (phVelocity.Dx * -VelocityTransferFactor) + (pInitialVelocity.Dx * VelocityTransferFactor)
-	phVelocity.Dy = (phVelocity.Dy * -VelocityTransferFactor) + (pInitialVelocity.Dy * VelocityTransferFactor)
-
-	p.setVelocity(pVelocity)
-	ph.setVelocity(phVelocity)
+	collide(p, ph, PlayerRestitution)
 	ph.setLastPlayerHit(p)
 	p.setLastPlayerHit(ph)
-	p.setPointsDebounce(PointsDebounceTicks)
-	ph.setPointsDebounce(PointsDebounceTicks)
-	p.setPDebounce(PlayerDebounceTicks)
+	p.setPointsDebounce(scaleTicks(PointsDebounceTicks))
+	ph.setPointsDebounce(scaleTicks(PointsDebounceTicks))
+	p.setPDebounce(scaleTicks(PlayerDebounceTicks))
 }
 
 // ApplyGravity applys a vector towards given position
@@ -284,8 +362,8 @@ func (p *Player) ApplyGravity(h *Hole) {
 	gravityVector.normalize()
 
 	//Velocity is affected by how close you are, the size of the hole, and a damping factor.
-	pVelocity.Dx += gravityVector.Dx * inverseMagnitude * h.GetRadius() * gravityDamping
-	pVelocity.Dy += gravityVector.Dy * inverseMagnitude * h.GetRadius() * gravityDamping
+	pVelocity.Dx += gravityVector.Dx * inverseMagnitude * h.GetRadius() * PlayerGravityDamping * timeScale()
+	pVelocity.Dy += gravityVector.Dy * inverseMagnitude * h.GetRadius() * PlayerGravityDamping * timeScale()
 
 	p.setVelocity(pVelocity)
 }
@@ -347,3 +425,66 @@ func (p *Player) KeyUpHandler(key int) {
 
 	p.setControls(pControls)
 }
+
+// AddEffect grants the effect to the player for EffectDuration, restarting it
+// if the player already has it
+func (p *Player) AddEffect(e Effect) {
+	effects := make([]ActiveEffect, 0, len(p.Effects)+1)
+	for _, active := range p.Effects {
+		if active.Effect != e {
+			effects = append(effects, active)
+		}
+	}
+	p.Effects = append(effects, ActiveEffect{Effect: e, Remaining: EffectDuration})
+}
+
+// HasEffect returns whether the effect is active on the player
+func (p *Player) HasEffect(e Effect) bool {
+	for _, active := range p.Effects {
+		if active.Effect == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ClearEffects removes every effect from the player
+func (p *Player) ClearEffects() {
+	p.Effects = nil
+}
+
+// EffectNames returns the names of the player's active effects, joined by commas
+func (p *Player) EffectNames() string {
+	names := make([]string, len(p.Effects))
+	for i, active := range p.Effects {
+		names[i] = string(active.Effect)
+	}
+	return strings.Join(names, ",")
+}
+
+// updateEffects counts down the player's effects by a tick, removing those that ran out
+// The list is replaced rather than changed in place, as it may still be being sent to clients
+func (p *Player) updateEffects() {
+	if len(p.Effects) == 0 {
+		return
+	}
+
+	elapsed := 1 / TickRate
+	var effects []ActiveEffect
+	for _, active := range p.Effects {
+		active.Remaining -= elapsed
+		if active.Remaining > 0 {
+			effects = append(effects, active)
+		}
+	}
+	p.Effects = effects
+}
+
+// boosted returns the given player setting, raised by SpeedBoostFactor while
+// the player has the speed effect
+func (p *Player) boosted(v float64) float64 {
+	if p.HasEffect(SpeedEffect) {
+		return v * SpeedBoostFactor
+	}
+	return v
+}
@@ -3,8 +3,6 @@ package models
 import (
 	"math"
 	"testing"
-
-	"github.com/gorilla/websocket"
 )
 
 const (
@@ -38,10 +36,10 @@ func TestAddPoints(t *testing.T) {
 }
 
 func TestCreatePlayer(t *testing.T) {
-	ws := new(websocket.Conn)
+	conn := CreateMemoryConnection()
 
 	//Test initialization of player
-	p := CreatePlayer(testNamePlayerTest, testColorPlayerTest, ws)
+	p := CreatePlayer(testNamePlayerTest, testColorPlayerTest, conn)
 	p.Position = centerPosPlayerTest
 
 	// Test name assignment of player
@@ -50,6 +48,27 @@ func TestCreatePlayer(t *testing.T) {
 	}
 }
 
+func TestSendAndClose(t *testing.T) {
+	conn := CreateMemoryConnection()
+	p := CreatePlayer(testNamePlayerTest, testColorPlayerTest, conn)
+
+	err := p.Send(&Message{Type: "death"})
+	if err != nil {
+		t.Fatalf("Error sending message: %v", err)
+	}
+	if messages := conn.Messages(); len(messages) != 1 || messages[0].Type != "death" {
+		t.Errorf("Expected a death message to be sent, got %v", messages)
+	}
+
+	p.Close()
+	if !conn.IsClosed() {
+		t.Error("Expected the connection to be closed")
+	}
+	if err := p.Send(&Message{Type: "death"}); err != ErrConnectionClosed {
+		t.Errorf("Expected sending to a closed connection to fail, got %v", err)
+	}
+}
+
 func TestUpdatePosition(t *testing.T) {
 	//Mock player and info
 	p := new(Player)
@@ -145,39 +164,6 @@ func TestUpdatePosition(t *testing.T) {
 		})
 	}
 }
-func TestHitJunk(t *testing.T) {
-	testCases := []struct {
-		description    string
-		playerVelocity Velocity
-	}{
-		{"Moving NW", Velocity{5, -5}},
-		{"Moving NE", Velocity{5, 5}},
-		{"Moving SW", Velocity{-5, -5}},
-		{"Moving SE", Velocity{-5, 5}},
-		{"Stationary", Velocity{0, 0}},
-		{"Moving N", Velocity{5, 0}},
-		{"Moving E", Velocity{0, 5}},
-		{"Moving S", Velocity{-5, 0}},
-		{"Moving W", Velocity{0, -5}},
-	}
-	for _, tc := range testCases {
-		t.Run(tc.description, func(t *testing.T) {
-			p := new(Player)
-			p.Velocity = tc.playerVelocity
-			p.hitJunk()
-			playerDx := tc.playerVelocity.Dx * JunkBounceFactor
-			playerDy := tc.playerVelocity.Dy * JunkBounceFactor
-
-			if p.Velocity.Dx != playerDx {
-				t.Error("Error calculating player Dx hitting junk")
-			}
-			if p.Velocity.Dy != playerDy {
-				t.Error("Error calculating player Dy hitting junk")
-			}
-		})
-	}
-}
-
 func TestKeyHandler(t *testing.T) {
 	p := new(Player)
 	testCases := []struct {
@@ -220,7 +206,11 @@ func TestPlayerBumpPlayer(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
 			p1 := CreatePlayer("player1", testColorPlayerTest, nil)
+			p1.Position = centerPosPlayerTest
+
+			// Place the other player right in the path of the first
 			p2 := CreatePlayer("player2", testColorPlayerTest, nil)
+			p2.Position = Position{centerPosPlayerTest.X + tc.playerVelocity.Dx, centerPosPlayerTest.Y + tc.playerVelocity.Dy}
 
 			p1.Velocity = tc.playerVelocity
 
@@ -243,7 +233,7 @@ func TestPlayerKillPlayer(t *testing.T) {
 		shouldAwardPoints bool
 	}{
 		{"Award Points", Position{40, 50}, Position{45, 50}, Velocity{5, 0}, Position{50, 50}, true},
-		{"No Points", Position{5, 50}, Position{10, 50}, Velocity{5, 0}, Position{350, 50}, false},
+		{"No Points", Position{40, 50}, Position{45, 50}, Velocity{5, 0}, Position{350, 50}, false},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.description, func(t *testing.T) {
@@ -293,3 +283,25 @@ func areCirclesColliding(obj Object, other Object) bool {
 	otherPosition := other.GetPosition()
 	return math.Pow(objPosition.X-otherPosition.X, 2)+math.Pow(objPosition.Y-otherPosition.Y, 2) <= math.Pow(obj.GetRadius()+other.GetRadius(), 2)
 }
+
+func TestLastInput(t *testing.T) {
+	p := CreatePlayer(testNamePlayerTest, testColorPlayerTest, nil)
+
+	testCases := []struct {
+		description string
+		sequence    uint64
+		expected    uint64
+	}{
+		{"First input", 1, 1},
+		{"Newer input", 5, 5},
+		{"Older input", 3, 5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			p.SetLastInput(tc.sequence)
+			if p.GetLastInput() != tc.expected {
+				t.Errorf("Got last input %d. Expected %d", p.GetLastInput(), tc.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"math/rand"
+
+	"github.com/rs/xid"
+)
+
+// Effect is what a power-up does to the player that picks it up
+type Effect string
+
+// Power-up effects
+const (
+	// SpeedEffect raises the player's acceleration and top speed by SpeedBoostFactor
+	SpeedEffect Effect = "speed"
+	// ShieldEffect keeps holes from pulling in or swallowing the player
+	ShieldEffect Effect = "shield"
+	// HeavyEffect multiplies the player's mass by HeavyMassFactor when bumping
+	HeavyEffect Effect = "heavy"
+	// MagnetEffect pulls junk within MagnetRadius towards the player
+	MagnetEffect Effect = "magnet"
+)
+
+// Effects lists every effect a power-up may grant
+var Effects = []Effect{SpeedEffect, ShieldEffect, HeavyEffect, MagnetEffect}
+
+// effectColors is the color power-ups are drawn in for each effect
+var effectColors = map[Effect]string{
+	SpeedEffect:  "#F39C12",
+	ShieldEffect: "#1ABC9C",
+	HeavyEffect:  "#95A5A6",
+	MagnetEffect: "#9B59B6",
+}
+
+// Power-up settings, overridden by the game configuration
+// Durations and intervals are measured in seconds
+var (
+	PowerUpRadius    = 15.0
+	PowerUpInterval  = 10.0
+	MaxPowerUps      = 5
+	EffectDuration   = 10.0
+	SpeedBoostFactor = 1.5
+	HeavyMassFactor  = 3.0
+	MagnetRadius     = 250.0
+	MagnetStrength   = 0.3
+)
+
+// PowerUp is a pickup that grants its effect to the first player to touch it
+type PowerUp struct {
+	ID       string   `json:"id"`
+	Position Position `json:"position"`
+	Effect   Effect   `json:"effect"`
+}
+
+// CreatePowerUp initializes and returns a power-up with the given effect
+func CreatePowerUp(position Position, effect Effect) *PowerUp {
+	return &PowerUp{
+		ID:       xid.New().String(),
+		Position: position,
+		Effect:   effect,
+	}
+}
+
+// RandomEffect picks one of Effects at random
+func RandomEffect() Effect {
+	return Effects[rand.Intn(len(Effects))]
+}
+
+// GetID returns the ID of this power-up
+func (pu PowerUp) GetID() string {
+	return pu.ID
+}
+
+// GetColor returns the color of this power-up's effect
+func (pu PowerUp) GetColor() string {
+	return effectColors[pu.Effect]
+}
+
+// GetPosition returns the position of this power-up
+func (pu PowerUp) GetPosition() Position {
+	return pu.Position
+}
+
+// GetVelocity returns the velocity of this power-up, which never moves
+func (pu PowerUp) GetVelocity() Velocity {
+	return Velocity{}
+}
+
+// GetRadius returns the radius of this power-up
+func (pu PowerUp) GetRadius() float64 {
+	return PowerUpRadius
+}
+
+// GetMass returns 0, power-ups are never bumped
+func (pu PowerUp) GetMass() float64 {
+	return 0
+}
+
+// ActiveEffect is an effect a player picked up and the seconds it has left
+type ActiveEffect struct {
+	Effect    Effect  `json:"effect"`
+	Remaining float64 `json:"remaining"`
+}
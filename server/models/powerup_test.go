@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestEffectsExpire(t *testing.T) {
+	p := CreatePlayer(testNamePlayerTest, testColorPlayerTest, nil)
+	p.AddEffect(SpeedEffect)
+	p.AddEffect(ShieldEffect)
+	p.Effects[0].Remaining = 1.5 / TickRate
+
+	p.UpdatePosition(testHeightPlayerTest, testWidthPlayerTest)
+	if !p.HasEffect(SpeedEffect) || !p.HasEffect(ShieldEffect) {
+		t.Fatalf("Expected both effects to be active after a tick, got %v", p.Effects)
+	}
+
+	p.UpdatePosition(testHeightPlayerTest, testWidthPlayerTest)
+	if p.HasEffect(SpeedEffect) || !p.HasEffect(ShieldEffect) {
+		t.Errorf("Expected only the speed effect to run out, got %v", p.Effects)
+	}
+
+	p.AddEffect(ShieldEffect)
+	if len(p.Effects) != 1 || p.Effects[0].Remaining != EffectDuration {
+		t.Errorf("Expected picking up an active effect to restart it, got %v", p.Effects)
+	}
+}
+
+func TestSpeedEffect(t *testing.T) {
+	testCases := []struct {
+		description string
+		effect      Effect
+		maxVelocity float64
+	}{
+		{"Without speed", HeavyEffect, MaxVelocity},
+		{"With speed", SpeedEffect, MaxVelocity * SpeedBoostFactor},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			p := CreatePlayer(testNamePlayerTest, testColorPlayerTest, nil)
+			p.Position = centerPosPlayerTest
+			p.Velocity = Velocity{0, -2 * tc.maxVelocity}
+			p.AddEffect(tc.effect)
+
+			p.UpdatePosition(testHeightPlayerTest, testWidthPlayerTest)
+			if !isWithinTolerance(p.Velocity.magnitude(), tc.maxVelocity, roundingError5SigFig) {
+				t.Errorf("Expected the speed to be capped at %g, got %g", tc.maxVelocity, p.Velocity.magnitude())
+			}
+		})
+	}
+}
+
+func TestHeavyEffect(t *testing.T) {
+	heavy := CreatePlayer("heavy", testColorPlayerTest, nil)
+	light := CreatePlayer("light", testColorPlayerTest, nil)
+	heavy.AddEffect(HeavyEffect)
+	heavy.Position = centerPosPlayerTest
+	light.Position = Position{centerPosPlayerTest.X + PlayerRadius, centerPosPlayerTest.Y}
+	heavy.Velocity = Velocity{5, 0}
+	light.Velocity = Velocity{-5, 0}
+
+	heavy.HitPlayer(light)
+
+	if heavy.Velocity.magnitude() >= light.Velocity.magnitude() {
+		t.Errorf("Expected the heavy player to be knocked back less, got %v and %v", heavy.Velocity, light.Velocity)
+	}
+}
+
+func TestMagnetEffect(t *testing.T) {
+	p := CreatePlayer(testNamePlayerTest, testColorPlayerTest, nil)
+	p.Position = centerPosPlayerTest
+	near := CreateJunk(Position{centerPosPlayerTest.X + MagnetRadius/2, centerPosPlayerTest.Y})
+	far := CreateJunk(Position{centerPosPlayerTest.X + 2*MagnetRadius, centerPosPlayerTest.Y})
+
+	near.AttractTo(p)
+	far.AttractTo(p)
+
+	if near.Velocity.Dx >= 0 || near.Velocity.Dy != 0 {
+		t.Errorf("Expected junk within the magnet's radius to be pulled towards the player, got %v", near.Velocity)
+	}
+	if far.Velocity != (Velocity{}) {
+		t.Errorf("Expected junk outside the magnet's radius to stay put, got %v", far.Velocity)
+	}
+}
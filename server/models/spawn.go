@@ -0,0 +1,186 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Spawn validation related constants
+const (
+	MaxNameLength    = 20
+	maxStackedMarks  = 2
+	nameSpecialChars = "-_.'"
+)
+
+// ProfanityFilter decides whether a player name is offensive
+type ProfanityFilter interface {
+	IsProfane(name string) bool
+}
+
+// NameFilter rejects offensive player names
+// Replace it before games start to use a different word list or service
+var NameFilter ProfanityFilter = CreateWordFilter(defaultBannedWords, defaultAllowedWords)
+
+var defaultBannedWords = []string{
+	"fuck", "shit", "cunt", "bitch", "asshole", "dick", "cock", "pussy",
+	"whore", "slut", "bastard", "nigger", "nigga", "faggot", "retard",
+}
+
+// defaultAllowedWords are names and words that contain a banned word
+var defaultAllowedWords = []string{
+	"scunthorpe", "hancock", "hitchcock", "peacock", "cockpit", "cocktail", "cockatoo",
+	"dickens", "dickinson", "dickson", "benedick",
+}
+
+// WordFilter flags names containing any of its words
+// Names are compared in lower case with separators removed, common letter
+// substitutions undone and Cyrillic and Greek look-alike letters folded to
+// Latin, so "F.u_C k", "5h1t" and "ѕhіt" are caught
+// Allowed words are cut out of names before they are checked, so "Hancock" and
+// "Scunthorpe" are not
+type WordFilter struct {
+	words   []string
+	allowed []string
+}
+
+// CreateWordFilter constructs a filter for the given banned and allowed words
+func CreateWordFilter(words []string, allowed []string) *WordFilter {
+	return &WordFilter{words: compactAll(words), allowed: compactAll(allowed)}
+}
+
+// compactAll compacts each word, dropping any left empty
+func compactAll(words []string) []string {
+	var compacted []string
+	for _, w := range words {
+		if w = compact(w); w != "" {
+			compacted = append(compacted, w)
+		}
+	}
+	return compacted
+}
+
+// IsProfane returns whether the name contains a banned word outside of the allowed words
+func (f *WordFilter) IsProfane(name string) bool {
+	name = compact(name)
+	for _, w := range f.allowed {
+		// a separator keeps the letters on either side from joining into a new word
+		name = strings.Replace(name, w, " ", -1)
+	}
+	for _, w := range f.words {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+	return false
+}
+
+var substitutions = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// confusables maps lower case Cyrillic and Greek letters to the Latin letters they look like
+var confusables = map[rune]rune{
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'н': 'h', 'і': 'i', 'ј': 'j', 'к': 'k',
+	'м': 'm', 'п': 'n', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'г': 'r', 'ѕ': 's', 'т': 't', 'ѵ': 'v',
+	'ԝ': 'w', 'х': 'x', 'у': 'y',
+	'α': 'a', 'β': 'b', 'ϲ': 'c', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'γ': 'y',
+}
+
+// compact lower cases s, undoes letter substitutions, folds look-alike letters
+// and drops everything but letters
+func compact(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if sub, ok := substitutions[r]; ok {
+			r = sub
+		}
+		if latin, ok := confusables[r]; ok {
+			r = latin
+		}
+		if unicode.IsLetter(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Validate normalises the requested name and country, returning an error
+// message for the client if either is not allowed
+// Names are trimmed to single spaces between words and may hold letters,
+// numbers, spaces and -_.' only
+// Countries are upper case codes from countryCodes, or empty for no flag
+func (m *SpawnHandlerMessage) Validate() error {
+	name, err := normalizeName(m.Name)
+	if err != nil {
+		return err
+	}
+	if NameFilter != nil && NameFilter.IsProfane(name) {
+		return &ErrorMessage{Code: ErrorInvalidName, Message: "name is not allowed"}
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(m.Country))
+	if country != "" && !countryCodes[country] {
+		return &ErrorMessage{Code: ErrorInvalidCountry, Message: fmt.Sprintf("unknown country %q", m.Country)}
+	}
+
+	m.Name = name
+	m.Country = country
+	return nil
+}
+
+// normalizeName applies NFKC normalization, which composes accents and folds
+// fullwidth, circled and other compatibility forms to plain letters, then removes
+// invisible characters and collapses whitespace, and checks the length and
+// characters of the result
+// Other look-alike letters are left for players to use, NameFilter folds them when checking names
+func normalizeName(name string) (string, error) {
+	if !utf8.ValidString(name) {
+		return "", &ErrorMessage{Code: ErrorInvalidName, Message: "name is not valid UTF-8"}
+	}
+
+	var b strings.Builder
+	marks := 0
+	space := false
+	for _, r := range norm.NFKC.String(name) {
+		switch {
+		case unicode.Is(unicode.Cf, r):
+			// zero width and text direction characters
+			continue
+		case unicode.IsSpace(r):
+			space = b.Len() > 0
+			continue
+		case unicode.IsControl(r):
+			return "", &ErrorMessage{Code: ErrorInvalidName, Message: "name contains control characters"}
+		case unicode.IsMark(r):
+			marks++
+			if marks > maxStackedMarks {
+				return "", &ErrorMessage{Code: ErrorInvalidName, Message: "name contains too many combining marks"}
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(nameSpecialChars, r):
+			marks = 0
+		default:
+			return "", &ErrorMessage{Code: ErrorInvalidName, Message: fmt.Sprintf("name contains %q", r)}
+		}
+
+		if space {
+			b.WriteRune(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+
+	normalized := b.String()
+	length := utf8.RuneCountInString(normalized)
+	if length == 0 {
+		return "", &ErrorMessage{Code: ErrorInvalidName, Message: "name is empty"}
+	}
+	if length > MaxNameLength {
+		return "", &ErrorMessage{Code: ErrorInvalidName, Message: fmt.Sprintf("name is longer than %d characters", MaxNameLength)}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSpawn(t *testing.T) {
+	testCases := []struct {
+		description string
+		name        string
+		country     string
+		wantName    string
+		wantCountry string
+		wantCode    string
+	}{
+		{"Valid", "testy", "CA", "testy", "CA", ""},
+		{"No country", "testy", "", "testy", "", ""},
+		{"Lower case country", "testy", "gb-sct", "testy", "GB-SCT", ""},
+		{"Whitespace", "  te\tst  y ", "CA", "te st y", "CA", ""},
+		{"Fullwidth letters", "\uff54\uff45\uff53\uff54\uff59", "CA", "testy", "CA", ""},
+		{"Zero width characters", "te\u200bst\u202ey", "CA", "testy", "CA", ""},
+		{"Accents", "Zoë José", "CA", "Zoë José", "CA", ""},
+		{"Decomposed accents", "Zoe\u0308 Jose\u0301", "CA", "Zoë José", "CA", ""},
+		{"Compatibility forms", "\ufb01sh \u24e3\u2460", "CA", "fish t1", "CA", ""},
+		{"Non latin", "玩家 1", "JP", "玩家 1", "JP", ""},
+		{"Empty", "", "CA", "", "", ErrorInvalidName},
+		{"Only spaces", "   ", "CA", "", "", ErrorInvalidName},
+		{"Too long", strings.Repeat("a", MaxNameLength+1), "CA", "", "", ErrorInvalidName},
+		{"Symbols", "<script>", "CA", "", "", ErrorInvalidName},
+		{"Control characters", "te\x00sty", "CA", "", "", ErrorInvalidName},
+		{"Stacked marks", "q\u0301\u0302\u0303", "CA", "", "", ErrorInvalidName},
+		{"Invalid UTF-8", "te\xffsty", "CA", "", "", ErrorInvalidName},
+		{"Profanity", "5H1T_lord", "CA", "", "", ErrorInvalidName},
+		{"Circled profanity", "\u24e2\u24d7\u24d8\u24e3", "CA", "", "", ErrorInvalidName},
+		{"Unknown country", "testy", "ZZ", "", "", ErrorInvalidCountry},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			m := SpawnHandlerMessage{Name: tc.name, Country: tc.country}
+			err := m.Validate()
+
+			if tc.wantCode != "" {
+				if e, ok := err.(*ErrorMessage); !ok || e.Code != tc.wantCode {
+					t.Errorf("Expected error %s, got %v", tc.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected spawn to be valid, got %v", err)
+			}
+			if m.Name != tc.wantName || m.Country != tc.wantCountry {
+				t.Errorf("Expected %q from %q, got %q from %q", tc.wantName, tc.wantCountry, m.Name, m.Country)
+			}
+		})
+	}
+}
+
+func TestWordFilter(t *testing.T) {
+	f := CreateWordFilter([]string{"heck"}, []string{"check"})
+
+	// the look-alikes are Cyrillic and Greek letters
+	for _, name := range []string{"heck", "HeCk", "h.e_c k", "h3ck", "oh heck no", "hеcк", "HΕCK", "һеск", "check heck", "checkheck"} {
+		if !f.IsProfane(name) {
+			t.Errorf("Expected %q to be caught", name)
+		}
+	}
+	for _, name := range []string{"hello", "hecate", "check", "Ch3ck_mate", "checkchecker"} {
+		if f.IsProfane(name) {
+			t.Errorf("Expected %q to be allowed", name)
+		}
+	}
+}
+
+func TestDefaultNameFilter(t *testing.T) {
+	testCases := []struct {
+		name    string
+		profane bool
+	}{
+		{"Hancock", false},
+		{"Dickens", false},
+		{"Scunthorpe", false},
+		{"Alfred Hitchcock", false},
+		{"dick", true},
+		{"big_c0ck", true},
+		{"Dickens the dick", true},
+		{"Scunthorpe cunt", true},
+	}
+
+	for _, tc := range testCases {
+		if got := NameFilter.IsProfane(tc.name); got != tc.profane {
+			t.Errorf("IsProfane(%q) = %v; want %v", tc.name, got, tc.profane)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+// Team is a group of players that score together
+// IDs start at 1, so a player whose Team is 0 plays for themselves
+type Team struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Color  string `json:"color"`
+	Points int    `json:"points"`
+}